@@ -0,0 +1,162 @@
+package beerweb
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ABV is a beer's alcohol by volume, parsed from however the venue chose to
+// write it. The original text is kept around for display when it couldn't
+// be understood.
+type ABV struct {
+	// Text is the ABV exactly as the venue listed it.
+	Text string
+
+	low, high float64
+	known     bool
+}
+
+// abvPat matches the whole of an ABV: a number or range of numbers, each
+// with an optional percent sign, maybe with "ABV" before or after. Anything
+// more, like "12oz" or "IBU 45", is something else.
+var abvPat = regexp.MustCompile(`(?i)^(?:abv\s*:?\s*)?(\d+(?:[.,]\d+)?)\s*%?(?:\s*(?:-|–|—|to)\s*(\d+(?:[.,]\d+)?)\s*%?)?(?:\s*abv)?$`)
+
+// ParseABV understands the usual ways an ABV is written on a beer list, like
+// "6.5", "6.5%", "6,5 %", "ABV 6.5", "abv: 6.5%", "6.5% ABV" and ranges like
+// "5-6%". Anything else, including "N/A" and "12oz", results in an ABV that
+// isn't Known, but still has Text.
+func ParseABV(s string) ABV {
+	a := ABV{Text: strings.TrimSpace(s)}
+	m := abvPat.FindStringSubmatch(a.Text)
+	if m == nil {
+		return a
+	}
+	lo, loOK := parsePercent(m[1])
+	hi, hiOK := lo, loOK
+	if m[2] != "" {
+		hi, hiOK = parsePercent(m[2])
+	}
+	if loOK && hiOK && lo <= hi {
+		a.low, a.high, a.known = lo, hi, true
+	}
+	return a
+}
+
+// ABVPercent returns a known ABV of v percent.
+func ABVPercent(v float64) ABV {
+	a := ABV{low: v, high: v, known: true}
+	a.Text = a.String()
+	return a
+}
+
+func parsePercent(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil || v < 0 || v > 100 {
+		return 0, false
+	}
+	return v, true
+}
+
+// Known reports whether the ABV was understood.
+func (a ABV) Known() bool {
+	return a.known
+}
+
+// Value returns the ABV as a percentage. For a range, this is the midpoint.
+// An unknown ABV has a value of zero, so check Known first.
+func (a ABV) Value() float64 {
+	return (a.low + a.high) / 2
+}
+
+// Range returns the lowest and highest values of the ABV, which are the
+// same unless the venue gave a range.
+func (a ABV) Range() (low, high float64) {
+	return a.low, a.high
+}
+
+// IsRange reports whether the venue listed a range of values.
+func (a ABV) IsRange() bool {
+	return a.known && a.low != a.high
+}
+
+// Less orders ABVs by strength, with unknown ABVs last.
+func (a ABV) Less(b ABV) bool {
+	if a.known != b.known {
+		return a.known
+	}
+	return a.Value() < b.Value()
+}
+
+// String formats a known ABV consistently, like "6.5%" or "5-6%", and
+// otherwise returns the original text.
+func (a ABV) String() string {
+	if !a.known {
+		return a.Text
+	}
+	if a.IsRange() {
+		return formatPercent(a.low) + "-" + formatPercent(a.high) + "%"
+	}
+	return formatPercent(a.low) + "%"
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// abvJSON is the JSON representation of an ABV.
+type abvJSON struct {
+	Text  string   `json:"text"`
+	Value *float64 `json:"value,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// MarshalJSON writes the ABV as an object holding the original text and,
+// if it's known, its value. Ranges include their min and max as well. An
+// ABV that's entirely missing is written as null.
+func (a ABV) MarshalJSON() ([]byte, error) {
+	if a.Text == "" && !a.known {
+		return []byte("null"), nil
+	}
+	j := abvJSON{Text: a.Text}
+	if a.known {
+		v := a.Value()
+		j.Value = &v
+	}
+	if a.IsRange() {
+		j.Min, j.Max = &a.low, &a.high
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON accepts the object written by MarshalJSON, as well as a
+// plain string or number, which is how ABVs used to be written.
+func (a *ABV) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*a = ABV{}
+	case string:
+		*a = ParseABV(v)
+	case float64:
+		*a = ABVPercent(v)
+	default:
+		var j abvJSON
+		if err := json.Unmarshal(data, &j); err != nil {
+			return err
+		}
+		*a = ParseABV(j.Text)
+		switch {
+		case j.Min != nil && j.Max != nil:
+			a.low, a.high, a.known = *j.Min, *j.Max, true
+		case j.Value != nil:
+			a.low, a.high, a.known = *j.Value, *j.Value, true
+		}
+	}
+	return nil
+}
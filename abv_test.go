@@ -0,0 +1,74 @@
+package beerweb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseABV(t *testing.T) {
+	tests := []struct {
+		in        string
+		known     bool
+		low, hi   float64
+		formatted string
+	}{
+		{"6.5", true, 6.5, 6.5, "6.5%"},
+		{"6.5%", true, 6.5, 6.5, "6.5%"},
+		{" 6,5 % ", true, 6.5, 6.5, "6.5%"},
+		{"ABV 6.5", true, 6.5, 6.5, "6.5%"},
+		{"abv: 7%", true, 7, 7, "7%"},
+		{"ABV:5.2", true, 5.2, 5.2, "5.2%"},
+		{"6.5% ABV", true, 6.5, 6.5, "6.5%"},
+		{"5-6%", true, 5, 6, "5-6%"},
+		{"5% - 6%", true, 5, 6, "5-6%"},
+		{"5 to 6", true, 5, 6, "5-6%"},
+		{"5–6.5%", true, 5, 6.5, "5-6.5%"},
+		{"6-5%", false, 0, 0, "6-5%"},
+		{"N/A", false, 0, 0, "N/A"},
+		{"", false, 0, 0, ""},
+		{"12oz", false, 0, 0, "12oz"},
+		{"IBU 45", false, 0, 0, "IBU 45"},
+		{"$7", false, 0, 0, "$7"},
+		{"150%", false, 0, 0, "150%"},
+		{"pint 6.5%", false, 0, 0, "pint 6.5%"},
+	}
+	for _, tt := range tests {
+		a := ParseABV(tt.in)
+		if a.Known() != tt.known {
+			t.Errorf("ParseABV(%q).Known() = %v, want %v", tt.in, a.Known(), tt.known)
+			continue
+		}
+		if lo, hi := a.Range(); lo != tt.low || hi != tt.hi {
+			t.Errorf("ParseABV(%q).Range() = %v, %v, want %v, %v", tt.in, lo, hi, tt.low, tt.hi)
+		}
+		if got := a.String(); got != tt.formatted {
+			t.Errorf("ParseABV(%q).String() = %q, want %q", tt.in, got, tt.formatted)
+		}
+	}
+}
+
+func TestABVJSON(t *testing.T) {
+	for _, in := range []string{"6.5%", "5-6%", "N/A", ""} {
+		a := ParseABV(in)
+		data, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ABV
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshaling %s: %v", data, err)
+		}
+		if got != a {
+			t.Errorf("%q round-tripped through %s as %#v", in, data, got)
+		}
+	}
+
+	// The old formats are still read.
+	var a ABV
+	if err := json.Unmarshal([]byte(`6.5`), &a); err != nil || a.String() != "6.5%" {
+		t.Errorf("reading a number: got %v, %v", a, err)
+	}
+	if err := json.Unmarshal([]byte(`"7%"`), &a); err != nil || a.String() != "7%" {
+		t.Errorf("reading a string: got %v, %v", a, err)
+	}
+}
@@ -3,31 +3,139 @@
 package beerweb
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 )
 
 // Taplister is an interface to be called and have returned a list of beers,
 // allowing for multiple strategies like HTML table scraping and API access.
+// Implementations should give up and return ctx.Err() once the context is
+// done.
 type Taplister interface {
-	FetchBeers() ([]Beer, error)
+	FetchBeers(ctx context.Context) ([]Beer, error)
 	Venue() string
 	URL() string // TODO This is slightly awkward.
 }
 
+// LegacyTaplister is the original, context-unaware form of Taplister.
+//
+// Deprecated: Implement Taplister instead. Use AdaptLegacy to keep an
+// existing implementation working in the meantime.
+type LegacyTaplister interface {
+	FetchBeers() ([]Beer, error)
+	Venue() string
+	URL() string
+}
+
+// AdaptLegacy wraps a LegacyTaplister so it satisfies Taplister. The wrapped
+// fetch can't actually be interrupted, so on cancellation the adapter just
+// stops waiting for it and lets it finish in the background.
+func AdaptLegacy(tl LegacyTaplister) Taplister {
+	return legacyTaplister{tl}
+}
+
+type legacyTaplister struct {
+	tl LegacyTaplister
+}
+
+func (l legacyTaplister) FetchBeers(ctx context.Context) ([]Beer, error) {
+	type result struct {
+		beers []Beer
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		beers, err := l.tl.FetchBeers()
+		ch <- result{beers, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.beers, r.err
+	}
+}
+
+func (l legacyTaplister) Venue() string { return l.tl.Venue() }
+func (l legacyTaplister) URL() string   { return l.tl.URL() }
+
 type Taplist struct {
 	Venue string `json:"venue"`
 	URL   string `json:"url"`
 	Beers []Beer `json:"beers"`
 }
 
+// Slug returns a URL-friendly identifier for a venue, which stays the same
+// as long as the venue's name does.
+func (tl Taplist) Slug() string {
+	return Slug(tl.Venue)
+}
+
+// Slug turns a venue name like "Chuck's Hop Shop (Greenwood)" into a
+// URL-friendly identifier like "chucks-hop-shop-greenwood".
+func Slug(venue string) string {
+	var (
+		s    strings.Builder
+		dash bool
+	)
+	for _, r := range strings.ToLower(venue) {
+		switch {
+		case r == '\'' || r == '’':
+			// Keep possessives together.
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && s.Len() > 0 {
+				s.WriteByte('-')
+			}
+			dash = false
+			s.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+	return s.String()
+}
+
 // Beer describes a beer by brewery, name, and any other available attributes.
 type Beer struct {
 	Brewery string `json:"brewery"`
 	Name    string `json:"name"`
 	Style   string `json:"style"`
-	ABV     string `json:"abv"`
+	ABV     ABV    `json:"abv"`
 	Origin  string `json:"origin"`
+
+	// These are only listed by some venues.
+	IBU     string  `json:"ibu,omitempty"`
+	Tap     string  `json:"tap,omitempty"`
+	Serving string  `json:"serving,omitempty"` // e.g., draft, nitro, cask
+	Prices  []Price `json:"prices,omitempty"`
+}
+
+// Price is what a venue charges for a particular pour of a beer.
+type Price struct {
+	Size   string `json:"size,omitempty"` // e.g., pint, 10oz, growler fill
+	Amount string `json:"amount"`
+}
+
+// String formats the price like "pint $7", or just the amount if there's no
+// serving size.
+func (p Price) String() string {
+	if p.Size == "" {
+		return p.Amount
+	}
+	return p.Size + " " + p.Amount
+}
+
+// PriceList formats all the prices of a beer in a single string.
+func (b Beer) PriceList() string {
+	prices := make([]string, len(b.Prices))
+	for i, p := range b.Prices {
+		prices[i] = p.String()
+	}
+	return strings.Join(prices, ", ")
 }
 
 // String formats the Beer as a pretty-ish string.
@@ -45,13 +153,17 @@ func (b Beer) String() string {
 		s.WriteString(" | ")
 		s.WriteString(b.Style)
 	}
-	if b.ABV != "" {
+	if b.ABV.Text != "" {
 		s.WriteString(" | ")
-		s.WriteString(b.ABV)
-		if !strings.HasSuffix(b.ABV, "%") {
-			s.WriteByte('%')
+		s.WriteString(b.ABV.String())
+		if b.ABV.Known() {
+			s.WriteString(" abv")
 		}
-		s.WriteString(" abv")
+	}
+	if b.IBU != "" {
+		s.WriteString(" | ")
+		s.WriteString(b.IBU)
+		s.WriteString(" ibu")
 	}
 	return s.String()
 }
@@ -62,191 +174,82 @@ func (b Beer) Valid() bool {
 	return b.Brewery != "" && b.Name != ""
 }
 
-// TextTable renders a list of beers entry in compliance with the configured
-// field widths.
-type TextTable struct {
-	beers []Beer
-
-	BreweryWidth,
-	NameWidth,
-	StyleWidth,
-	ABVWidth,
-	OriginWidth int
+// FetchOptions controls how FetchAll treats each venue.
+type FetchOptions struct {
+	// Timeout bounds each attempt at fetching a venue. Zero means no
+	// limit beyond that of the context passed to FetchAll.
+	Timeout time.Duration
+	// Attempts is the number of times a venue is tried before giving up.
+	// Values less than one are treated as one.
+	Attempts int
+	// RetryDelay is how long to wait between attempts.
+	RetryDelay time.Duration
 }
 
-// Add saves a beer entry, and compares a its field widths to the
-// currently-stored format widths, and updates any of them that are shorter
-// that the current beer's field widths.
-func (tt *TextTable) Add(b Beer) {
-	tt.beers = append(tt.beers, b)
-	if l := len([]rune(b.Brewery)); l > tt.BreweryWidth {
-		tt.BreweryWidth = l
-	}
-	if l := len([]rune(b.Name)); l > tt.NameWidth {
-		tt.NameWidth = l
-	}
-	if l := len([]rune(b.Style)); l > tt.StyleWidth {
-		tt.StyleWidth = l
-	}
-	if l := len([]rune(b.ABV)); l > tt.ABVWidth {
-		tt.ABVWidth = l
-	}
-	if l := len([]rune(b.Origin)); l > tt.OriginWidth {
-		tt.OriginWidth = l
-	}
+// Result is the outcome of fetching a single venue. Taplist always has its
+// Venue and URL set, and has Beers only if Err is nil.
+type Result struct {
+	Taplist  Taplist
+	Duration time.Duration
+	Attempts int
+	Err      error
 }
 
-// NewTextTable builds a model for a nicely-formatted text table based, given
-// a slice of Beer values.
-func NewTextTable(beers []Beer) *TextTable {
-	table := &TextTable{}
-	for _, beer := range beers {
-		table.Add(beer)
-	}
-	return table
-}
+// Results holds the outcome of FetchAll for each venue, in the order the
+// venues were given.
+type Results []Result
 
-func (tt *TextTable) header() string {
-	var s strings.Builder
-	columns := []struct {
-		name  string
-		width *int
-	}{
-		{"Brewery", &tt.BreweryWidth},
-		{"Name", &tt.NameWidth},
-		{"Style", &tt.StyleWidth},
-		{"ABV", &tt.ABVWidth},
-		{"Origin", &tt.OriginWidth},
-	}
-	for _, column := range columns {
-		if *column.width == 0 {
-			continue
-		}
-		// Handle the case where the column name is longer than any
-		// value present in the column. This is pretty ugly since we're
-		// mutating the TextTable state inside of the header() func.
-		// There's definitely a much better way to do this, but, later.
-		if l := len(column.name); l > *column.width {
-			*column.width = l
+// Taplists returns the taplists of the venues that were fetched successfully.
+func (rs Results) Taplists() []Taplist {
+	taplists := make([]Taplist, 0, len(rs))
+	for _, r := range rs {
+		if r.Err == nil {
+			taplists = append(taplists, r.Taplist)
 		}
-		s.WriteString("| ")
-		s.WriteString(column.name)
-		s.WriteString(strings.Repeat(" ", *column.width-len([]rune(column.name))))
-		s.WriteByte(' ')
 	}
-	s.WriteByte('|')
-	return s.String()
+	return taplists
 }
 
-// Format renders the fields of a Beer using a minimum width for each field,
-// bordered by spaces and a pipe character. This is useful for writing a list
-// of beers as a table that can be easily read.
-func (tt TextTable) String() string {
-	var s strings.Builder
-	header := tt.header()
-	underline := strings.Repeat("=", tt.Width())
-	s.WriteString(underline)
-	s.WriteByte('\n')
-	s.WriteString(header)
-	s.WriteByte('\n')
-	s.WriteString(underline)
-	s.WriteByte('\n')
-
-	for _, b := range tt.beers {
-		fields := []struct {
-			value string
-			width int
-		}{
-			{b.Brewery, tt.BreweryWidth},
-			{b.Name, tt.NameWidth},
-			{b.Style, tt.StyleWidth},
-			{b.ABV, tt.ABVWidth},
-			{b.Origin, tt.OriginWidth},
-		}
-		for _, field := range fields {
-			if field.width == 0 {
-				continue
-			}
-			s.WriteString("| ")
-			s.WriteString(field.value)
-			s.WriteString(strings.Repeat(" ", field.width-len([]rune(field.value))+1))
+// Err returns a FetchAllError holding the error for each venue that couldn't
+// be fetched, or nil if all of them were.
+func (rs Results) Err() error {
+	var err FetchAllError
+	for _, r := range rs {
+		if r.Err != nil {
+			err = append(err, r.Err)
 		}
-		s.WriteString("|\n")
 	}
-	s.WriteString(underline)
-	return s.String()
-}
-
-// Width returns the entire with of a formatted row, including space padding
-// and separator characters.
-func (tt TextTable) Width() int {
-	numZeroWidth := 0
-	if tt.BreweryWidth == 0 {
-		numZeroWidth++
-	}
-	if tt.NameWidth == 0 {
-		numZeroWidth++
-	}
-	if tt.StyleWidth == 0 {
-		numZeroWidth++
-	}
-	if tt.ABVWidth == 0 {
-		numZeroWidth++
-	}
-	if tt.OriginWidth == 0 {
-		numZeroWidth++
+	// Explicitly return nil if there have been no issues, since a nil
+	// FetchAllError will fail a comparison to nil as an error.
+	if len(err) == 0 {
+		return nil
 	}
-
-	width := tt.BreweryWidth +
-		tt.NameWidth +
-		tt.StyleWidth +
-		tt.ABVWidth +
-		tt.OriginWidth
-
-	// account for padding, pipe separator chars, and fields that won't be rendered
-	width += 16 - numZeroWidth*3
-	return width
+	return err
 }
 
 // FetchAll is a helper to concurrently get all the beers for
-// a slice of Taplisters.
+// a slice of Taplisters. Each venue's result is reported separately, so one
+// failing venue doesn't hide the others. All fetches are abandoned when ctx
+// is canceled.
 //
 // TODO This is kind of ugly, but it allows us to use a common function
 // for fetching all venue's beers for all clients (e.g., CLI, web).
-func FetchAll(venues []Taplister) ([]Taplist, error) {
-	respCh := make(chan response)
-	taplists := make([]Taplist, 0, len(venues))
-
-	var err FetchAllError
-
-	defer func() { close(respCh) }()
+func FetchAll(ctx context.Context, venues []Taplister, opts FetchOptions) Results {
+	results := make(Results, len(venues))
 
-	for _, tl := range venues {
-		go fetch(tl, respCh)
+	var wg sync.WaitGroup
+	wg.Add(len(venues))
+	for i, tl := range venues {
+		go func(i int, tl Taplister) {
+			defer wg.Done()
+			results[i] = fetch(ctx, tl, opts)
+		}(i, tl)
 	}
-
-	for i := len(venues); i > 0; i-- {
-		tl := <-respCh
-		if tl.err != nil {
-			err = append(err, tl.err)
-			continue
-		}
-		taplists = append(taplists, Taplist{
-			Venue: tl.venue,
-			URL:   tl.url,
-			Beers: tl.beers,
-		})
-	}
-	// We need to explicitly return nil for the error value here if there have
-	// been no issues, since the error variable declared at the top of this
-	// function will fail a comparison to nil, even if there were no errors
-	// encountered during the fetch.
-	if len(err) == 0 {
-		return taplists, nil
-	}
-	return taplists, err
+	wg.Wait()
+	return results
 }
 
+// FetchAllError collects the errors of all venues that failed to be fetched.
 type FetchAllError []error
 
 func (e FetchAllError) Error() string {
@@ -258,23 +261,81 @@ func (e FetchAllError) Error() string {
 	return b.String()
 }
 
-// TODO This can be simplified
-type response struct {
-	venue string
-	url   string
-	beers []Beer
-	err   error
+// Unwrap allows errors.Is and errors.As to look into each venue's error.
+func (e FetchAllError) Unwrap() []error {
+	return e
 }
 
-func fetch(tl Taplister, ch chan<- response) {
-	beers, err := tl.FetchBeers()
+// FetchError describes a venue that couldn't be fetched. The underlying
+// error is available via errors.Is and errors.As.
+type FetchError struct {
+	Venue    string
+	URL      string
+	Duration time.Duration
+	Attempts int
+	Err      error
+}
+
+func (e *FetchError) Error() string {
+	attempts := "1 attempt"
+	if e.Attempts != 1 {
+		attempts = fmt.Sprintf("%d attempts", e.Attempts)
+	}
+	return fmt.Sprintf("error fetching beers from %s after %s (%v): %v",
+		e.Venue, attempts, e.Duration.Round(time.Millisecond), e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+func fetch(ctx context.Context, tl Taplister, opts FetchOptions) Result {
+	var (
+		start = time.Now()
+		r     = Result{Taplist: Taplist{Venue: tl.Venue(), URL: tl.URL()}}
+		err   error
+	)
+	for r.Attempts < opts.Attempts || r.Attempts == 0 {
+		if r.Attempts > 0 && !sleep(ctx, opts.RetryDelay) {
+			break
+		}
+		r.Attempts++
+		r.Taplist.Beers, err = fetchOnce(ctx, tl, opts.Timeout)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	r.Duration = time.Since(start)
 	if err != nil {
-		ch <- response{err: fmt.Errorf("error fetching beers from %s: %v\n", tl.Venue(), err)}
-		return
+		r.Taplist.Beers = nil
+		r.Err = &FetchError{
+			Venue:    r.Taplist.Venue,
+			URL:      r.Taplist.URL,
+			Duration: r.Duration,
+			Attempts: r.Attempts,
+			Err:      err,
+		}
 	}
-	ch <- response{
-		venue: tl.Venue(),
-		url:   tl.URL(),
-		beers: beers,
+	return r
+}
+
+func fetchOnce(ctx context.Context, tl Taplister, timeout time.Duration) ([]Beer, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return tl.FetchBeers(ctx)
+}
+
+// sleep waits for d, and reports whether it did so without ctx ending first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
 	}
 }
@@ -0,0 +1,85 @@
+package beerweb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// legacyVenue is a LegacyTaplister that blocks until release is closed, if
+// it's set.
+type legacyVenue struct {
+	name    string
+	beers   []Beer
+	err     error
+	release chan struct{}
+}
+
+func (v legacyVenue) FetchBeers() ([]Beer, error) {
+	if v.release != nil {
+		<-v.release
+	}
+	return v.beers, v.err
+}
+
+func (v legacyVenue) Venue() string { return v.name }
+func (v legacyVenue) URL() string   { return "http://example.com/" + Slug(v.name) }
+
+func TestAdaptLegacy(t *testing.T) {
+	broken := errors.New("scraper broke")
+	venues := []Taplister{
+		AdaptLegacy(legacyVenue{name: "Pub", beers: []Beer{{Brewery: "Fremont", Name: "Lush"}}}),
+		AdaptLegacy(legacyVenue{name: "Bar", err: broken}),
+	}
+	results := FetchAll(context.Background(), venues, FetchOptions{Attempts: 2})
+
+	pub := results[0]
+	if pub.Err != nil || pub.Taplist.Venue != "Pub" || pub.Taplist.URL != "http://example.com/pub" || len(pub.Taplist.Beers) != 1 {
+		t.Errorf("got %+v for Pub", pub)
+	}
+	bar := results[1]
+	if !errors.Is(bar.Err, broken) || bar.Attempts != 2 {
+		t.Errorf("got %+v for Bar, want its error after 2 attempts", bar)
+	}
+}
+
+func TestAdaptLegacyCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	venues := []Taplister{AdaptLegacy(legacyVenue{name: "Slow Pub", release: release})}
+
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		opts FetchOptions
+		want error
+	}{
+		{"timeout", func() (context.Context, context.CancelFunc) {
+			return context.Background(), func() {}
+		}, FetchOptions{Timeout: 20 * time.Millisecond}, context.DeadlineExceeded},
+		{"canceled", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return ctx, cancel
+		}, FetchOptions{}, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			done := make(chan Results)
+			go func() { done <- FetchAll(ctx, venues, tt.opts) }()
+			// The legacy fetch never returns while the test runs, so
+			// FetchAll only returns if the adapter stops waiting for it.
+			select {
+			case results := <-done:
+				if err := results[0].Err; !errors.Is(err, tt.want) {
+					t.Errorf("got %v, want %v", err, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("FetchAll waited for the legacy fetch")
+			}
+		})
+	}
+}
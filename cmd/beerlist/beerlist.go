@@ -1,37 +1,339 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/store"
 	"github.com/ianfoo/beerweb/venues"
 )
 
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s [flags]\n", os.Args[0])
+	fmt.Fprintf(out, "       %s -changelog file [flags] history <brewery> <name>\n\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
 func main() {
-	jsonOutput := flag.Bool("json", false, "write output as JSON")
+	format := flag.String("format", "text", "write taplists as `format`: "+strings.Join(beerweb.RenderFormats(), ", "))
+	jsonOutput := flag.Bool("json", false, "write output as JSON; the same as -format json")
+	timeout := flag.Duration("timeout", 30*time.Second, "give up on a venue after this long")
+	attempts := flag.Int("attempts", 1, "number of times to try each venue")
+	diffFile := flag.String("diff", "", "show changes since the taplists in this file, saved from -json output")
+	venuesFile := flag.String("venues", "", "read venues from this file instead of using the built-in list")
+	changeFile := flag.String("changelog", "", "read taplist changes from this file, written by beerweb -changelog")
+	var filter beerweb.Filter
+	flag.StringVar(&filter.Venue, "venue", "", "only list venues whose name contains this")
+	flag.StringVar(&filter.Brewery, "brewery", "", "only list beers from breweries whose name contains this")
+	flag.StringVar(&filter.Style, "style", "", "only list beers whose style contains this")
+	flag.StringVar(&filter.Origin, "origin", "", "only list beers whose origin contains this")
+	flag.Float64Var(&filter.MinABV, "min-abv", 0, "only list beers of at least this `percent` ABV")
+	flag.Float64Var(&filter.MaxABV, "max-abv", 0, "only list beers of at most this `percent` ABV")
+	sortBy := flag.String("sort", "", "sort beers by these comma-separated `fields`, like brewery,-abv; a leading - sorts descending")
+	groupBy := flag.String("group-by", "", "list beers grouped by `field`: brewery, style or venue")
+	summary := flag.Bool("summary", false, "finish with a count of the beers and their average ABV")
+	tmplText := flag.String("template", "", "write each beer with this Go `template`, like '{{.Venue}}: {{.Brewery}} {{.Name}} {{.ABV}}'")
+	tmplFile := flag.String("template-file", "", "write each beer with the Go template in this `file`")
+	tmplPer := flag.String("template-per", "beer", "execute the template for each `beer` or taplist")
+	width := flag.Int("width", 0, "fit tables into this many `columns` (default: the terminal's width)")
+	wrap := flag.Bool("wrap", false, "wrap values that don't fit in a table's columns, rather than cutting them short")
+	columns := flag.String("columns", "", "show these comma-separated `fields` as table columns, in order, like brewery,name,abv")
+	border := flag.String("border", "ascii", "draw tables with `style` ascii, unicode, none or markdown")
+	color := flag.String("color", "auto", "highlight table headers and strong beers: `when` always, never, or auto for terminals")
+	flag.Usage = usage
 	flag.Parse()
-
-	taplists, err := beerweb.FetchAll(venues.Venues)
+	log.SetFlags(0)
+	if *jsonOutput {
+		*format = "json"
+	}
+	*jsonOutput = *format == "json"
+	renderer, err := newRenderer(*format, *tmplText, *tmplFile, *tmplPer)
 	if err != nil {
-		log.SetFlags(0)
-		log.Fatalln("error fetching beer lists:", err)
+		log.Fatalln(err)
+	}
+	if _, ok := renderer.(beerweb.TextRenderer); ok {
+		renderer, err = textRenderer(*columns, *border, *color, *width, *wrap)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	sortKeys, err := beerweb.ParseSortKeys(*sortBy)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *groupBy != "" {
+		if *format != "text" {
+			log.Fatalln("-group-by only works with -format text")
+		}
+		// Check the field before fetching anything.
+		if _, err := beerweb.GroupBy(nil, *groupBy); err != nil {
+			log.Fatalln(err)
+		}
 	}
 
-	if *jsonOutput {
-		enc := json.NewEncoder(os.Stdout)
-		enc.Encode(taplists)
+	if flag.Arg(0) == "history" {
+		if flag.NArg() != 3 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if *changeFile == "" {
+			log.Fatalln("history needs a change log; use -changelog")
+		}
+		if err := showHistory(*changeFile, flag.Arg(1), flag.Arg(2), *jsonOutput); err != nil {
+			log.Fatalln("error reading change log:", err)
+		}
 		return
 	}
 
-	for i, taplist := range taplists {
-		fmt.Println("Beer list for " + taplist.Venue)
-		fmt.Println(beerweb.NewTextTable(taplist.Beers))
-		if i < len(taplists)-1 {
+	taplisters := venues.Venues
+	if *venuesFile != "" {
+		var err error
+		if taplisters, err = venues.LoadFile(*venuesFile); err != nil {
+			log.Fatalln("error loading venues:", err)
+		}
+	}
+	// No sense fetching venues that won't be listed.
+	var selected []beerweb.Taplister
+	for _, tl := range taplisters {
+		if filter.MatchVenue(tl.Venue()) {
+			selected = append(selected, tl)
+		}
+	}
+	if len(selected) == 0 {
+		log.Fatalf("no venues match %q", filter.Venue)
+	}
+
+	results := beerweb.FetchAll(context.Background(), selected, beerweb.FetchOptions{
+		Timeout:    *timeout,
+		Attempts:   *attempts,
+		RetryDelay: time.Second,
+	})
+	taplists := results.Taplists()
+	for _, r := range results {
+		if r.Err != nil {
+			log.Println(r.Err)
+		}
+	}
+	if len(taplists) == 0 {
+		log.Fatalln("no beer lists could be fetched")
+	}
+	for i, tl := range taplists {
+		taplists[i] = filter.Apply(tl)
+		beerweb.SortBeers(taplists[i].Beers, sortKeys)
+	}
+
+	if *diffFile != "" {
+		diffs, err := diffSaved(*diffFile, taplists, filter)
+		if err != nil {
+			log.Fatalln("error reading saved beer lists:", err)
+		}
+		if *jsonOutput {
+			json.NewEncoder(os.Stdout).Encode(diffs)
+		} else {
+			for _, d := range diffs {
+				fmt.Println(d)
+			}
+		}
+	} else if *groupBy != "" {
+		if err := showGroups(renderer, taplists, *groupBy); err != nil {
+			log.Fatalln("error writing beer lists:", err)
+		}
+	} else if err := renderer.Render(os.Stdout, taplists); err != nil {
+		log.Fatalln("error writing beer lists:", err)
+	}
+	if *summary {
+		// Keep machine-readable output clean.
+		out := os.Stderr
+		if *format == "text" {
+			out = os.Stdout
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintln(out, beerweb.Summarize(taplists))
+	}
+
+	// Let scripts know that the output is incomplete.
+	if results.Err() != nil {
+		os.Exit(1)
+	}
+}
+
+// newRenderer returns the renderer for a format, or for a template if one is
+// given.
+func newRenderer(format, text, filename, per string) (beerweb.Renderer, error) {
+	if text != "" && filename != "" {
+		return nil, errors.New("use only one of -template and -template-file")
+	}
+	if filename != "" {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	if text == "" {
+		return beerweb.NewRenderer(format)
+	}
+	if format != "text" {
+		return nil, errors.New("-template can't be used with -format or -json")
+	}
+	if per != "beer" && per != "taplist" {
+		return nil, fmt.Errorf("-template-per should be beer or taplist, not %q", per)
+	}
+	r, err := beerweb.NewTemplateRenderer(text, per == "taplist")
+	if err != nil {
+		return nil, fmt.Errorf("bad template: %v", err)
+	}
+	return r, nil
+}
+
+// textRenderer returns the renderer for text tables, configured by flags.
+func textRenderer(columns, border, color string, width int, wrap bool) (beerweb.TextRenderer, error) {
+	r := beerweb.TextRenderer{MaxWidth: outputWidth(width), Wrap: wrap}
+	var err error
+	if columns != "" {
+		if r.Columns, err = beerweb.ParseColumns(columns); err != nil {
+			return r, err
+		}
+	}
+	if r.Border, err = beerweb.ParseBorder(border); err != nil {
+		return r, err
+	}
+	switch color {
+	case "always":
+		r.Color = true
+	case "never":
+	case "auto":
+		// See https://no-color.org.
+		_, terminal := terminalWidth(os.Stdout)
+		r.Color = terminal && os.Getenv("NO_COLOR") == ""
+	default:
+		return r, fmt.Errorf("-color should be always, never or auto, not %q", color)
+	}
+	return r, nil
+}
+
+// outputWidth returns the width to fit tables into: the width asked for, or
+// else $COLUMNS or the width of the terminal. Zero means no limit.
+func outputWidth(width int) int {
+	if width != 0 {
+		return width
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	if cols, ok := terminalWidth(os.Stdout); ok {
+		return cols
+	}
+	return 0
+}
+
+// diffSaved compares taplists to those previously saved to a file as JSON,
+// with the saved ones put through the same filter. Venues that aren't in the
+// file are compared to an empty taplist.
+func diffSaved(filename string, taplists []beerweb.Taplist, filter beerweb.Filter) ([]beerweb.TaplistDiff, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var saved []beerweb.Taplist
+	if err := json.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, err
+	}
+	diffs := make([]beerweb.TaplistDiff, 0, len(taplists))
+	for _, tl := range taplists {
+		var old beerweb.Taplist
+		for _, stl := range saved {
+			if stl.Venue == tl.Venue {
+				old = filter.Apply(stl)
+				break
+			}
+		}
+		diffs = append(diffs, beerweb.Diff(old, tl))
+	}
+	return diffs, nil
+}
+
+// showGroups lists the beers on the taplists in groups, each under a heading.
+func showGroups(r beerweb.Renderer, taplists []beerweb.Taplist, by string) error {
+	groups, err := beerweb.GroupBy(taplists, by)
+	if err != nil {
+		return err
+	}
+	by = strings.ToLower(by)
+	for i, g := range groups {
+		if i > 0 {
 			fmt.Println()
 		}
+		name := g.Name
+		if name == "" {
+			name = "(no " + by + ")"
+		}
+		heading := fmt.Sprintf("%s (%d)", name, g.Beers())
+		fmt.Println(heading)
+		fmt.Println(strings.Repeat("-", len([]rune(heading))))
+		if err := r.Render(os.Stdout, g.Taplists); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// showHistory prints when and where a beer has been on tap, according to a
+// change log.
+func showHistory(filename, brewery, name string, jsonOutput bool) error {
+	changes, err := store.OpenFileLog(filename)
+	if err != nil {
+		return err
+	}
+	defer changes.Close()
+	logged, err := changes.Changes()
+	if err != nil {
+		return err
+	}
+
+	h := beerweb.FindBeer(logged, brewery, name)
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(h)
+	}
+
+	fmt.Println(h.Brewery + " | " + h.Name)
+	if len(h.Stints) == 0 {
+		fmt.Println("Never seen on tap")
+		return nil
+	}
+	const timeFormat = "Jan 2, 2006 3:04 PM"
+	now := time.Now()
+	fmt.Println("First seen", h.FirstSeen().Format(timeFormat))
+	if len(h.OnTap()) > 0 {
+		fmt.Println("On tap now")
+	} else {
+		fmt.Println("Last seen", h.LastSeen(now).Format(timeFormat))
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Venue\tOn\tOff\tLasted")
+	for _, s := range h.Stints {
+		off := "still on tap"
+		if !s.OnTap() {
+			off = s.End.Format(timeFormat)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			s.Venue, s.Start.Format(timeFormat), off, beerweb.HumanDuration(s.Duration(now)))
 	}
+	return w.Flush()
 }
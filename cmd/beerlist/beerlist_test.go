@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewRendererTemplate(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.tmpl")
+	bad := filepath.Join(dir, "bad.tmpl")
+	ioutil.WriteFile(good, []byte("{{.Venue}}: {{.Name}}\n"), 0644)
+	ioutil.WriteFile(bad, []byte("{{.Venue"), 0644)
+
+	tests := []struct {
+		name               string
+		format, text, file string
+		per                string
+		wantErr            string
+		wantNotExist       bool
+	}{
+		{"text", "text", "{{.Name}}", "", "beer", "", false},
+		{"file", "text", "", good, "taplist", "", false},
+		{"missing file", "text", "", filepath.Join(dir, "missing.tmpl"), "beer", "", true},
+		{"parse error", "text", "", bad, "beer", "bad template", false},
+		{"both", "text", "{{.Name}}", good, "beer", "use only one", false},
+		{"with a format", "json", "{{.Name}}", "", "beer", "can't be used with -format", false},
+		{"per what", "text", "{{.Name}}", "", "venue", "beer or taplist", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRenderer(tt.format, tt.text, tt.file, tt.per)
+			switch {
+			case tt.wantNotExist:
+				if !os.IsNotExist(err) {
+					t.Errorf("got %v, want a missing file error", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Error(err)
+			case r == nil:
+				t.Error("no renderer")
+			}
+		})
+	}
+}
+
+func TestTextRendererColor(t *testing.T) {
+	// Output to a file isn't to a terminal, so auto means no color.
+	f, err := ioutil.TempFile(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+
+	tests := []struct {
+		color   string
+		want    bool
+		wantErr bool
+	}{
+		{"always", true, false},
+		{"never", false, false},
+		{"auto", false, false},
+		{"sometimes", false, true},
+	}
+	for _, tt := range tests {
+		r, err := textRenderer("", "ascii", tt.color, 0, false)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("-color %s got error %v", tt.color, err)
+			continue
+		}
+		if r.Color != tt.want {
+			t.Errorf("-color %s got Color %v, want %v", tt.color, r.Color, tt.want)
+		}
+	}
+}
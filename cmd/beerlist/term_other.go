@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd)
+
+package main
+
+import "os"
+
+// terminalWidth can't tell the width of a terminal on this system, so
+// beerlist falls back to $COLUMNS.
+func terminalWidth(f *os.File) (int, bool) {
+	return 0, false
+}
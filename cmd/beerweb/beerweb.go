@@ -1,217 +1,174 @@
-// A slapdash HTTP/HTML implementation to show the beer lists from the defined
-// venues.  Certain aspects of this implementation are a little offensive. This
-// should be corrected.
+// Beerweb serves the beer lists from the defined venues on the web, keeping
+// them up to date in the background. The real work is done by package
+// server; this just configures it.
+//
+// Every flag can also be set from the environment, with a variable named
+// after the flag, like BEERWEB_POLL_INTERVAL for -poll-interval. Flags on the
+// command line win.
 package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
-	"html/template"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"reflect"
-	"regexp"
 	"strings"
 	"sync"
-	"time"
+	"syscall"
 
 	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/digest"
+	"github.com/ianfoo/beerweb/server"
+	"github.com/ianfoo/beerweb/store"
 	"github.com/ianfoo/beerweb/venues"
+	"github.com/ianfoo/beerweb/webhook"
 )
 
-var (
-	addr = flag.String("addr", ":5050", "Address to listen on")
-)
-
-// TODO Do not use unassociated global variables to track the tap lists.
-var (
-	taplists []beerweb.Taplist
-	mu       sync.RWMutex
-)
-
-func init() {
-	if !strings.Contains(*addr, ":") {
-		*addr = ":" + *addr
-	}
-}
-
 func main() {
-	m := http.NewServeMux()
-	m.HandleFunc("/", beerHandler)
-	s := &http.Server{
-		Addr:              *addr,
-		ReadHeaderTimeout: 10 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		MaxHeaderBytes:    1 << 20,
-		Handler:           m,
+	c := server.DefaultConfig()
+	flag.StringVar(&c.Addr, "addr", c.Addr, "Address to listen on")
+	flag.DurationVar(&c.PollInterval, "poll-interval", c.PollInterval, "How often to fetch the taplists")
+	flag.DurationVar(&c.Fetch.Timeout, "fetch-timeout", c.Fetch.Timeout, "Give up on a venue after this long")
+	flag.IntVar(&c.Fetch.Attempts, "fetch-attempts", c.Fetch.Attempts, "Number of times to try each venue")
+	flag.DurationVar(&c.Fetch.RetryDelay, "retry-delay", c.Fetch.RetryDelay, "How long to wait before trying a venue again")
+	flag.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "Give up on writing a response after this long")
+	flag.IntVar(&c.ReadyPolls, "ready-polls", c.ReadyPolls, "Only report ready if every venue has been fetched within this many polls (0 to not require it)")
+	var (
+		venuesFile = flag.String("venues", "", "Read venues from this file instead of using the built-in list")
+		storeFile  = flag.String("store", "", "Keep taplist history in this file, rather than only in memory")
+		changeFile = flag.String("changelog", "", "Keep a log of taplist changes in this file, rather than only in memory")
+		watchFile  = flag.String("watch", "", "Alert when beers matching the rules in this file go on tap")
+		hooksFile  = flag.String("webhooks", "", "Deliver taplist changes to the webhooks listed in this file")
+		hooksLog   = flag.String("webhook-log", "", "Log webhook deliveries to this file, as JSON")
+		digestFile = flag.String("digest", "", "Mail a daily digest of taplist changes as configured in this file")
+	)
+	flag.Parse()
+	if err := envFlags(); err != nil {
+		log.Fatalln(err)
 	}
 
-	// Shut down the beer fetch operations.
-	shutdownCh := make(chan struct{})
-	s.RegisterOnShutdown(func() {
-		shutdownCh <- struct{}{}
-	})
-	go getBeers(shutdownCh)
-
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, os.Kill)
-	shutdownFinished := make(chan struct{})
-	go func() {
-		log.Println("listening on", s.Addr)
-		err := s.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			log.Println("server error:", err)
-			os.Exit(1)
+	taplisters := venues.Venues
+	if *venuesFile != "" {
+		var err error
+		if taplisters, err = venues.LoadFile(*venuesFile); err != nil {
+			log.Fatalln("error loading venues:", err)
 		}
-		log.Println("exiting")
-		shutdownFinished <- struct{}{}
-	}()
-
-	sig := <-sigCh
-	log.Println("shutting down after receiving signal", sig)
-	err := s.Shutdown(context.Background())
-	if err != nil {
-		log.Println("error shutting down:", err)
 	}
-	<-shutdownFinished
-}
 
-const pollInterval = 10 * time.Minute
+	if *storeFile != "" {
+		history, err := store.OpenFile(*storeFile)
+		if err != nil {
+			log.Fatalln("error opening store:", err)
+		}
+		defer history.Close()
+		c.Store = history
+	}
 
-func getBeers(shutdown <-chan struct{}) {
-	fetch := func() {
-		var (
-			t          = time.Now()
-			totalBeers int
-			err        error
-		)
-		defer func() {
-			log.Printf(
-				"fetched %d beers from %d venues in %v",
-				totalBeers, len(taplists), time.Since(t))
-		}()
+	if *changeFile != "" {
+		changes, err := store.OpenFileLog(*changeFile)
+		if err != nil {
+			log.Fatalln("error opening change log:", err)
+		}
+		defer changes.Close()
+		c.ChangeLog = changes
+	}
 
-		log.Println("fetching beers")
-		newTaplists, err := beerweb.FetchAll(venues.Venues)
+	if *watchFile != "" {
+		rules, err := beerweb.ReadWatchRules(*watchFile)
+		if err == nil {
+			c.Watcher, err = beerweb.NewWatcher(rules)
+		}
 		if err != nil {
-			if faErr, ok := err.(beerweb.FetchAllError); ok {
-				log.Println("error fetching taplists:", faErr)
-				return
-			}
-			panic(err)
+			log.Fatalln("error loading watch rules:", err)
 		}
+	}
 
-		mu.Lock()
-		for _, tl := range newTaplists {
-			// FIXME This is really ugly.
-			// But I wanted a quick way to show that the beer lists had changed.
-			// Proper taplist diffing will help this go away.
-			var oldTaplist *beerweb.Taplist
-			for _, otl := range taplists {
-				if tl.Venue == otl.Venue {
-					oldTaplist = &otl
-					break
-				}
-			}
-			if oldTaplist != nil && !reflect.DeepEqual(tl, oldTaplist) {
-				log.Printf("beers for %s have changed!", tl.Venue)
-			}
-			totalBeers += len(tl.Beers)
+	if *hooksFile != "" {
+		endpoints, err := webhook.ReadEndpoints(*hooksFile)
+		if err != nil {
+			log.Fatalln("error loading webhooks:", err)
 		}
-		taplists = newTaplists
-		mu.Unlock()
+		c.Notifier = webhook.New(endpoints)
+		logDelivery, closeLog, err := deliveryLogger(*hooksLog)
+		if err != nil {
+			log.Fatalln("error opening webhook log:", err)
+		}
+		defer closeLog()
+		c.Notifier.Log = logDelivery
 	}
-	fetch()
 
-	t := time.NewTicker(pollInterval)
-	for {
-		select {
-		case <-t.C:
-			fetch()
-		case <-shutdown:
-			log.Println("exiting beer fetch goroutine")
-			t.Stop()
-			return
+	if *digestFile != "" {
+		dc, err := digest.ReadConfig(*digestFile)
+		if err != nil {
+			log.Fatalln("error loading digest config:", err)
 		}
+		c.Digest = &dc
 	}
-}
 
-func addPercent(s string) string {
-	if !pctPat.MatchString(s) {
-		return s
+	s, err := server.New(taplisters, c)
+	if err != nil {
+		log.Fatalln("error starting server:", err)
 	}
-	if !strings.HasSuffix(s, "%") {
-		return s + "%"
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := s.ListenAndServe(ctx); err != nil && err != http.ErrServerClosed {
+		log.Println("server error:", err)
+		os.Exit(1)
 	}
-	return s
+	log.Println("exiting")
 }
 
-var (
-	pctPat = regexp.MustCompile("^[0-9.]+")
-	tmpl   = template.Must(template.New("Taplists").
-		Funcs(template.FuncMap{"percent": addPercent}).
-		Parse(tmplStr))
-)
-
-func beerHandler(rw http.ResponseWriter, r *http.Request) {
-	t := time.Now()
-	defer log.Printf("serviced request from %s in %v", r.RemoteAddr, time.Since(t))
-	mu.RLock()
-	defer mu.RUnlock()
-	tmpl.ExecuteTemplate(rw, "Taplists", taplists)
+// envFlags sets each flag that wasn't given on the command line from its
+// environment variable, if that's set.
+func envFlags() error {
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] || err != nil {
+			return
+		}
+		name := "BEERWEB_" + strings.ToUpper(strings.Replace(f.Name, "-", "_", -1))
+		if v, ok := os.LookupEnv(name); ok {
+			if setErr := f.Value.Set(v); setErr != nil {
+				err = fmt.Errorf("bad value %q for %s: %v", v, name, setErr)
+			}
+		}
+	})
+	return err
 }
 
-var semanticUICDN = `<link rel="stylesheet" type="text/css"` +
-	`href="https://cdnjs.cloudflare.com/ajax/libs/semantic-ui/2.3.3/semantic.min.css"/>`
-
-var tmplStr = `<!DOCTYPE html>
-<html>` + semanticUICDN + `<head>
-<title>Beer Lists</title>
-</head>
-<body>
-<div class="ui header segment">
-<div class="ui center aligned container">
-<h1>Beer Lists</h1>
-</div>
-</div>
-{{range $taplist := .}}
-<div class="ui one column container">
-<div class="column">
-
-<table class="ui celled striped inverted compact table">
-  <thead>
-  <tr>
-  <th colspan="5" class="ui">Beers at {{ $taplist.Venue }}</th>
-  <tr>
-    <th>Brewery</th>
-    <th>Name</th>
-    <th>Style</th>
-    <th>ABV</th>
-    <th>Origin</th>
-  </tr>
-  </thead>
-  <tbody>
-{{range $beer := $taplist.Beers}}
-  <tr>
-    <td>{{ $beer.Brewery }}</td>
-    <td>{{ $beer.Name }}</td>
-    <td>{{ $beer.Style }}</td>
-    <td>{{ percent $beer.ABV }}</td>
-    <td>{{ $beer.Origin }}</td>
-  </tr>
-{{end}}
-</tbody>
-</table>
-</div>
-</div>
-<div class="ui hidden divider"></div>
-{{end}}
-<div class="ui footer segment">
-<div class="ui center aligned container">
-<p>Generated using <a href="https://github.com/ianfoo/beerweb">beerweb</a>.</p>
-</div>
-</div>
-</body>
-</html>`
+// deliveryLogger returns a function that logs webhook deliveries to the
+// named file as JSON, one per line. Failures go to the standard log too, and
+// they're all that's logged if there's no file.
+func deliveryLogger(filename string) (func(webhook.Delivery), func() error, error) {
+	logFailure := func(d webhook.Delivery) {
+		if !d.OK() {
+			log.Println(d)
+		}
+	}
+	if filename == "" {
+		return logFailure, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		mu  sync.Mutex
+		enc = json.NewEncoder(f)
+	)
+	return func(d webhook.Delivery) {
+		logFailure(d)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(d); err != nil {
+			log.Println("error logging webhook delivery:", err)
+		}
+	}, f.Close, nil
+}
@@ -0,0 +1,225 @@
+package beerweb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TaplistDiff describes how a venue's taplist changed between two fetches.
+type TaplistDiff struct {
+	Venue   string       `json:"venue"`
+	Added   []Beer       `json:"added,omitempty"`
+	Removed []Beer       `json:"removed,omitempty"`
+	Changed []BeerChange `json:"changed,omitempty"`
+}
+
+// BeerChange is a beer that stayed on the list, but had some of its details
+// change, like an ABV being corrected or a style being filled in.
+type BeerChange struct {
+	// Key identifies which of the beer's listings changed, as one of
+	// ListingKeys.
+	Key    string   `json:"key,omitempty"`
+	Old    Beer     `json:"old"`
+	New    Beer     `json:"new"`
+	Fields []string `json:"fields"`
+}
+
+// Key returns the identity of a beer, which stays the same as long as its
+// brewery and name do, disregarding case and spacing.
+func (b Beer) Key() string {
+	return normalizeKey(b.Brewery) + "|" + normalizeKey(b.Name)
+}
+
+func normalizeKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// Diff compares two versions of a venue's taplist. Beers are matched up by
+// their Key. If a beer is listed more than once (on nitro as well as
+// regular draft, say), the listings are matched up in order.
+func Diff(prev, next Taplist) TaplistDiff {
+	d := TaplistDiff{Venue: next.Venue}
+	if d.Venue == "" {
+		d.Venue = prev.Venue
+	}
+
+	prevBeers := make(map[string]Beer, len(prev.Beers))
+	for _, b := range keyBeers(prev.Beers) {
+		prevBeers[b.key] = b.Beer
+	}
+	seen := make(map[string]bool, len(next.Beers))
+	for _, b := range keyBeers(next.Beers) {
+		seen[b.key] = true
+		pb, ok := prevBeers[b.key]
+		if !ok {
+			d.Added = append(d.Added, b.Beer)
+			continue
+		}
+		if fields := ChangedFields(pb, b.Beer); len(fields) > 0 {
+			d.Changed = append(d.Changed, BeerChange{Key: b.key, Old: pb, New: b.Beer, Fields: fields})
+		}
+	}
+	for _, b := range keyBeers(prev.Beers) {
+		if !seen[b.key] {
+			d.Removed = append(d.Removed, b.Beer)
+		}
+	}
+	return d
+}
+
+type keyedBeer struct {
+	key string
+	Beer
+}
+
+// keyBeers pairs each beer with its listing key.
+func keyBeers(beers []Beer) []keyedBeer {
+	keyed := make([]keyedBeer, len(beers))
+	for i, key := range ListingKeys(beers) {
+		keyed[i] = keyedBeer{key, beers[i]}
+	}
+	return keyed
+}
+
+// ListingKeys returns the keys Diff identifies each of the beers on a list
+// by. That's the beer's Key, numbered if the beer's listed more than once,
+// like "fremont|lush#2" for its second listing, so that they're unique
+// within the list.
+func ListingKeys(beers []Beer) []string {
+	var (
+		keys  = make([]string, len(beers))
+		count = make(map[string]int, len(beers))
+	)
+	for i, b := range beers {
+		key := b.Key()
+		count[key]++
+		keys[i] = listingKey(key, count[key])
+	}
+	return keys
+}
+
+// listingKey returns the key of the nth listing of a beer with key.
+func listingKey(key string, n int) string {
+	if n == 1 {
+		return key
+	}
+	return key + "#" + strconv.Itoa(n)
+}
+
+// Keys returns the listing keys of the beers that d added and removed, given
+// the beers listed before the change. Listings are matched up in order, so a
+// beer's added listings come after those it already had, and its removed
+// listings are the last of them.
+func (d TaplistDiff) Keys(prev []Beer) (added, removed []string) {
+	count := make(map[string]int, len(prev))
+	for _, b := range prev {
+		count[b.Key()]++
+	}
+	gone := make(map[string]int, len(d.Removed))
+	for _, b := range d.Removed {
+		gone[b.Key()]++
+	}
+	for _, b := range d.Removed {
+		key := b.Key()
+		removed = append(removed, listingKey(key, count[key]-gone[key]+1))
+		gone[key]--
+	}
+	for _, b := range d.Added {
+		key := b.Key()
+		count[key]++
+		added = append(added, listingKey(key, count[key]))
+	}
+	return added, removed
+}
+
+// diffFields are the fields of a Beer that are compared by Diff, named as
+// they are in JSON.
+var diffFields = []string{
+	"brewery", "name", "style", "abv", "ibu", "origin", "tap", "serving", "prices",
+}
+
+// ChangedFields returns the names of the fields that differ between two
+// versions of a beer, as in BeerChange.
+func ChangedFields(prev, next Beer) []string {
+	var fields []string
+	for _, f := range diffFields {
+		if fieldValue(prev, f) != fieldValue(next, f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Empty reports whether nothing changed.
+func (d TaplistDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String describes the changes, one beer per line, prefixed with + for
+// added beers, - for removed beers and ~ for changed beers.
+func (d TaplistDiff) String() string {
+	var s strings.Builder
+	s.WriteString("Changes for ")
+	s.WriteString(d.Venue)
+	if d.Empty() {
+		s.WriteString(": none")
+		return s.String()
+	}
+	s.WriteByte(':')
+	for _, b := range d.Added {
+		s.WriteString("\n+ ")
+		s.WriteString(b.String())
+	}
+	for _, b := range d.Removed {
+		s.WriteString("\n- ")
+		s.WriteString(b.String())
+	}
+	for _, c := range d.Changed {
+		s.WriteString("\n~ ")
+		s.WriteString(c.String())
+	}
+	return s.String()
+}
+
+// String describes the change like
+// `Brewery | Name: abv "6%" -> "6.5%", style "" -> "IPA"`.
+func (c BeerChange) String() string {
+	var s strings.Builder
+	s.WriteString(c.New.Brewery)
+	s.WriteString(" | ")
+	s.WriteString(c.New.Name)
+	s.WriteByte(':')
+	for i, f := range c.Fields {
+		if i > 0 {
+			s.WriteByte(',')
+		}
+		prev, next := fieldValue(c.Old, f), fieldValue(c.New, f)
+		fmt.Fprintf(&s, " %s %q -> %q", f, prev, next)
+	}
+	return s.String()
+}
+
+func fieldValue(b Beer, field string) string {
+	switch field {
+	case "brewery":
+		return b.Brewery
+	case "name":
+		return b.Name
+	case "style":
+		return b.Style
+	case "abv":
+		return b.ABV.String()
+	case "ibu":
+		return b.IBU
+	case "origin":
+		return b.Origin
+	case "tap":
+		return b.Tap
+	case "serving":
+		return b.Serving
+	case "prices":
+		return b.PriceList()
+	}
+	return ""
+}
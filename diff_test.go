@@ -0,0 +1,182 @@
+package beerweb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var (
+		darkStar = Beer{Brewery: "Fremont", Name: "Dark Star", Style: "Stout", ABV: ParseABV("8%")}
+		nitro    = Beer{Brewery: "Fremont", Name: "Dark Star", Style: "Stout", Serving: "nitro"}
+		lush     = Beer{Brewery: "Fremont", Name: "Lush", Style: "IPA"}
+		pliny    = Beer{Brewery: "Russian River", Name: "Pliny the Elder"}
+	)
+	with := func(b Beer, change func(*Beer)) Beer {
+		change(&b)
+		return b
+	}
+
+	tests := []struct {
+		name       string
+		prev, next []Beer
+		added      []Beer
+		removed    []Beer
+		changed    [][]string // fields of each change
+	}{
+		{"unchanged", []Beer{darkStar, lush}, []Beer{darkStar, lush}, nil, nil, nil},
+		{"reordered", []Beer{darkStar, lush}, []Beer{lush, darkStar}, nil, nil, nil},
+		{"first fetch", nil, []Beer{darkStar, lush}, []Beer{darkStar, lush}, nil, nil},
+		{"added and removed", []Beer{darkStar, lush}, []Beer{lush, pliny}, []Beer{pliny}, []Beer{darkStar}, nil},
+		{"everything gone", []Beer{darkStar, lush}, nil, nil, []Beer{darkStar, lush}, nil},
+		{
+			"style filled in",
+			[]Beer{pliny}, []Beer{with(pliny, func(b *Beer) { b.Style = "DIPA" })},
+			nil, nil, [][]string{{"style"}},
+		},
+		{
+			"abv and price changed",
+			[]Beer{darkStar},
+			[]Beer{with(darkStar, func(b *Beer) {
+				b.ABV = ParseABV("8.2")
+				b.Prices = []Price{{Size: "pint", Amount: "$7"}}
+			})},
+			nil, nil, [][]string{{"abv", "prices"}},
+		},
+		{
+			"abv written differently",
+			[]Beer{darkStar}, []Beer{with(darkStar, func(b *Beer) { b.ABV = ParseABV("ABV 8") })},
+			nil, nil, nil,
+		},
+		{
+			"name recased",
+			[]Beer{lush}, []Beer{with(lush, func(b *Beer) { b.Name = "LUSH " })},
+			nil, nil, [][]string{{"name"}},
+		},
+		{"second listing added", []Beer{darkStar, lush}, []Beer{darkStar, lush, nitro}, []Beer{nitro}, nil, nil},
+		{"second listing removed", []Beer{darkStar, lush, nitro}, []Beer{darkStar, lush}, nil, []Beer{nitro}, nil},
+		{
+			// Listings are matched in order, so this looks like the
+			// first listing turning into the second.
+			"first listing removed",
+			[]Beer{darkStar, lush, nitro}, []Beer{lush, nitro},
+			nil, []Beer{nitro}, [][]string{{"abv", "serving"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diff(Taplist{Venue: "Pub", Beers: tt.prev}, Taplist{Venue: "Pub", Beers: tt.next})
+			if d.Venue != "Pub" {
+				t.Errorf("venue %q, want Pub", d.Venue)
+			}
+			if !reflect.DeepEqual(d.Added, tt.added) {
+				t.Errorf("added %v, want %v", d.Added, tt.added)
+			}
+			if !reflect.DeepEqual(d.Removed, tt.removed) {
+				t.Errorf("removed %v, want %v", d.Removed, tt.removed)
+			}
+			var changed [][]string
+			for _, c := range d.Changed {
+				changed = append(changed, c.Fields)
+			}
+			if !reflect.DeepEqual(changed, tt.changed) {
+				t.Errorf("changed fields %v, want %v", changed, tt.changed)
+			}
+			if d.Empty() != (tt.added == nil && tt.removed == nil && tt.changed == nil) {
+				t.Errorf("Empty() = %v", d.Empty())
+			}
+		})
+	}
+}
+
+func TestDiffVenue(t *testing.T) {
+	// A venue that failed to fetch has no name in its new taplist.
+	d := Diff(Taplist{Venue: "Pub"}, Taplist{})
+	if d.Venue != "Pub" {
+		t.Errorf("venue %q, want Pub", d.Venue)
+	}
+}
+
+func TestListingKeys(t *testing.T) {
+	beers := []Beer{
+		{Brewery: "Fremont", Name: "Dark Star"},
+		{Brewery: "Fremont", Name: "Lush"},
+		{Brewery: " fremont", Name: "Dark  Star"},
+		{Brewery: "Fremont", Name: "Dark Star", Serving: "cask"},
+	}
+	want := []string{"fremont|dark star", "fremont|lush", "fremont|dark star#2", "fremont|dark star#3"}
+	if got := ListingKeys(beers); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDiffString(t *testing.T) {
+	d := Diff(
+		Taplist{Venue: "Pub", Beers: []Beer{
+			{Brewery: "Fremont", Name: "Lush", ABV: ParseABV("6%")},
+			{Brewery: "Fremont", Name: "Dark Star"},
+		}},
+		Taplist{Venue: "Pub", Beers: []Beer{
+			{Brewery: "Fremont", Name: "Lush", Style: "IPA", ABV: ParseABV("6.5%")},
+			{Brewery: "Russian River", Name: "Pliny the Elder"},
+		}},
+	)
+	want := `Changes for Pub:
++ Russian River | Pliny the Elder
+- Fremont | Dark Star
+~ Fremont | Lush: style "" -> "IPA", abv "6%" -> "6.5%"`
+	if got := d.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := Diff(Taplist{Venue: "Pub"}, Taplist{Venue: "Pub"}).String(); got != "Changes for Pub: none" {
+		t.Errorf("empty diff is %q", got)
+	}
+}
+
+func TestDiffKeys(t *testing.T) {
+	var (
+		draft = Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "draft"}
+		nitro = Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "nitro"}
+		cask  = Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "cask"}
+		lush  = Beer{Brewery: "Fremont", Name: "Lush"}
+	)
+	tests := []struct {
+		name           string
+		prev, next     []Beer
+		added, removed []string
+		changed        []string
+	}{
+		{"new beer", []Beer{lush}, []Beer{lush, draft}, []string{"fremont|dark star"}, nil, nil},
+		{"second listing", []Beer{draft, lush}, []Beer{draft, lush, nitro}, []string{"fremont|dark star#2"}, nil, nil},
+		{"last listing gone", []Beer{draft, nitro, lush}, []Beer{draft, lush}, nil, []string{"fremont|dark star#2"}, nil},
+		{
+			"first listing gone",
+			[]Beer{draft, nitro, cask}, []Beer{nitro, cask},
+			nil, []string{"fremont|dark star#3"}, []string{"fremont|dark star", "fremont|dark star#2"},
+		},
+		{
+			"every listing gone",
+			[]Beer{draft, lush, nitro}, []Beer{lush},
+			nil, []string{"fremont|dark star", "fremont|dark star#2"}, nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diff(Taplist{Beers: tt.prev}, Taplist{Beers: tt.next})
+			added, removed := d.Keys(tt.prev)
+			if !reflect.DeepEqual(added, tt.added) {
+				t.Errorf("added %q, want %q", added, tt.added)
+			}
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("removed %q, want %q", removed, tt.removed)
+			}
+			var changed []string
+			for _, c := range d.Changed {
+				changed = append(changed, c.Key)
+			}
+			if !reflect.DeepEqual(changed, tt.changed) {
+				t.Errorf("changed %q, want %q", changed, tt.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package digest summarizes how taplists have changed over a period, usually
+// a day, and mails the summary out.
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/layout"
+)
+
+// Digest describes the beers that went on and came off tap at each venue
+// between Since and Until.
+type Digest struct {
+	Since  time.Time
+	Until  time.Time
+	Venues []Venue
+}
+
+// Venue is the part of a digest for a single venue.
+type Venue struct {
+	Venue   string
+	Added   []beerweb.Beer
+	Removed []beerweb.Beer
+}
+
+// Summarize works out from a change log how each venue's taplist changed
+// between since and until. Only the net change counts, so a beer that came
+// and went within the period isn't mentioned. Venues whose taplists didn't
+// change are left out. A venue's first change is its first fetch, which has
+// every beer being added, so as with the server's notifications it's only
+// what changed after that counts.
+func Summarize(changes []beerweb.TaplistChange, since, until time.Time) Digest {
+	var (
+		before, after []beerweb.TaplistChange
+		fetched       = make(map[string]bool)
+	)
+	for _, c := range changes {
+		if c.Time.After(until) {
+			break
+		}
+		if !c.Time.After(since) || !fetched[c.Venue] {
+			before = append(before, c)
+		}
+		fetched[c.Venue] = true
+		after = append(after, c)
+	}
+
+	d := Digest{Since: since, Until: until}
+	old := make(map[string]beerweb.Taplist)
+	for _, snap := range beerweb.Replay(before) {
+		old[snap.Taplist.Venue] = snap.Taplist
+	}
+	for _, snap := range beerweb.Replay(after) {
+		diff := beerweb.Diff(old[snap.Taplist.Venue], snap.Taplist)
+		if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+			continue
+		}
+		d.Venues = append(d.Venues, Venue{
+			Venue:   snap.Taplist.Venue,
+			Added:   diff.Added,
+			Removed: diff.Removed,
+		})
+	}
+	return d
+}
+
+// Empty reports whether nothing changed at any venue.
+func (d Digest) Empty() bool {
+	return len(d.Venues) == 0
+}
+
+// ForVenues returns the digest with only the venues selected, by slug or by
+// part of their names, as with beerweb.Filter. No venues selects them all.
+func (d Digest) ForVenues(venues []string) Digest {
+	if len(venues) == 0 {
+		return d
+	}
+	selected := d
+	selected.Venues = nil
+	for _, v := range d.Venues {
+		for _, name := range venues {
+			if (beerweb.Filter{Venue: name}).MatchVenue(v.Venue) {
+				selected.Venues = append(selected.Venues, v)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// Subject returns a subject line for the digest when it's mailed.
+func (d Digest) Subject() string {
+	var added, removed int
+	for _, v := range d.Venues {
+		added += len(v.Added)
+		removed += len(v.Removed)
+	}
+	return fmt.Sprintf("Beer digest for %s: %d on, %d off",
+		d.Until.Format("Mon Jan 2"), added, removed)
+}
+
+// Text renders the digest as plain text, with a table of beers for each
+// venue.
+func (d Digest) Text() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "Taplist changes from %s to %s\n",
+		d.Since.Format(timeLayout), d.Until.Format(timeLayout))
+	if d.Empty() {
+		s.WriteString("\nNothing changed.\n")
+	}
+	for _, v := range d.Venues {
+		s.WriteString("\n" + v.Venue + "\n")
+		s.WriteString(strings.Repeat("-", len([]rune(v.Venue))) + "\n")
+		for _, part := range []struct {
+			title string
+			beers []beerweb.Beer
+		}{
+			{"On tap", v.Added},
+			{"Gone", v.Removed},
+		} {
+			if len(part.beers) == 0 {
+				continue
+			}
+			s.WriteString("\n" + part.title + ":\n")
+			s.WriteString(beerweb.NewTextTable(part.beers).String() + "\n")
+		}
+	}
+	return s.String()
+}
+
+// HTML renders the digest as an HTML page that looks like beerweb's.
+func (d Digest) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTmpl.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+const timeLayout = "Mon Jan 2 3:04 PM"
+
+var htmlTmpl = template.Must(layout.New("digest").
+	Funcs(template.FuncMap{
+		"format": func(t time.Time) string { return t.Format(timeLayout) },
+		"table": func(title string, beers []beerweb.Beer) layout.Table {
+			return layout.Table{Title: title, Beers: beers}
+		},
+	}).
+	Parse(htmlTmplStr))
+
+// The page is laid out like beerweb's, but plenty of mail clients won't load
+// its stylesheet, so the important styles are in the head as well.
+const htmlTmplStr = `{{template "header" "Beer Digest"}}
+<div class="ui center aligned container">
+<p>Taplist changes from {{ format .Since }} to {{ format .Until }}</p>
+</div>
+{{if not .Venues}}
+<div class="ui container"><div class="ui message"><div class="header">Nothing changed</div></div></div>
+{{end}}
+{{range .Venues}}
+<div class="ui one column container">
+<div class="column">
+{{if .Added}}{{template "beers" (table (printf "On tap at %s" .Venue) .Added)}}{{end}}
+{{if .Removed}}{{template "beers" (table (printf "Gone from %s" .Venue) .Removed)}}{{end}}
+</div>
+</div>
+{{end}}
+{{template "footer"}}
+{{define "style"}}<style>
+body { font-family: Lato, 'Helvetica Neue', Arial, sans-serif; }
+table.table { border-collapse: collapse; margin-bottom: 1em; background: #1b1c1d; color: #fff; }
+table.table th, table.table td { padding: 6px; text-align: left; }
+</style>{{end}}`
@@ -0,0 +1,152 @@
+package digest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+var (
+	dayStart = time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	darkStar = beerweb.Beer{Brewery: "Fremont", Name: "Dark Star"}
+	lush     = beerweb.Beer{Brewery: "Fremont", Name: "Lush"}
+	pliny    = beerweb.Beer{Brewery: "Russian River", Name: "Pliny the Elder"}
+	heady    = beerweb.Beer{Brewery: "The Alchemist", Name: "Heady Topper"}
+)
+
+func at(hour int) time.Time {
+	return dayStart.Add(time.Duration(hour) * time.Hour)
+}
+
+func change(hour int, venue string, added, removed []beerweb.Beer) beerweb.TaplistChange {
+	return beerweb.TaplistChange{
+		Time:        at(hour),
+		TaplistDiff: beerweb.TaplistDiff{Venue: venue, Added: added, Removed: removed},
+	}
+}
+
+// testLog has Local Pub's beers changing through the day, after they were
+// first fetched, and Other Pub's changing once.
+var testLog = []beerweb.TaplistChange{
+	change(1, "Other Pub", []beerweb.Beer{heady}, nil),
+	change(2, "Local Pub", []beerweb.Beer{darkStar, lush}, nil),
+	change(10, "Local Pub", []beerweb.Beer{pliny}, []beerweb.Beer{darkStar}),
+	change(12, "Local Pub", []beerweb.Beer{heady}, nil),
+	change(14, "Local Pub", nil, []beerweb.Beer{heady}),
+	change(16, "Other Pub", []beerweb.Beer{lush}, nil),
+	change(20, "Local Pub", nil, []beerweb.Beer{lush}),
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name         string
+		since, until int
+		want         []Venue
+	}{
+		// The first fetches aren't news, so only what changed after
+		// them counts.
+		{"whole day", 0, 24, []Venue{
+			{Venue: "Local Pub", Added: []beerweb.Beer{pliny}, Removed: []beerweb.Beer{darkStar, lush}},
+			{Venue: "Other Pub", Added: []beerweb.Beer{lush}},
+		}},
+		{"only first fetches", 0, 5, nil},
+		{"after opening", 2, 24, []Venue{
+			{Venue: "Local Pub", Added: []beerweb.Beer{pliny}, Removed: []beerweb.Beer{darkStar, lush}},
+			{Venue: "Other Pub", Added: []beerweb.Beer{lush}},
+		}},
+		{"came and went", 11, 15, nil},
+		{"up to a change", 2, 10, []Venue{
+			{Venue: "Local Pub", Added: []beerweb.Beer{pliny}, Removed: []beerweb.Beer{darkStar}},
+		}},
+		{"nothing since", 20, 24, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Summarize(testLog, at(tt.since), at(tt.until))
+			if !d.Since.Equal(at(tt.since)) || !d.Until.Equal(at(tt.until)) {
+				t.Errorf("digest is from %v to %v", d.Since, d.Until)
+			}
+			if !reflect.DeepEqual(d.Venues, tt.want) {
+				t.Errorf("got %+v, want %+v", d.Venues, tt.want)
+			}
+			if d.Empty() != (len(tt.want) == 0) {
+				t.Errorf("Empty() = %v", d.Empty())
+			}
+		})
+	}
+}
+
+func TestForVenues(t *testing.T) {
+	d := Summarize(testLog, at(0), at(24))
+	tests := []struct {
+		venues []string
+		want   []string
+	}{
+		{nil, []string{"Local Pub", "Other Pub"}},
+		{[]string{"local-pub"}, []string{"Local Pub"}},
+		{[]string{"other"}, []string{"Other Pub"}},
+		{[]string{"nowhere"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.venues, ","), func(t *testing.T) {
+			var got []string
+			for _, v := range d.ForVenues(tt.venues).Venues {
+				got = append(got, v.Venue)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := Summarize(testLog, at(2), at(24))
+	if got, want := d.Subject(), "Beer digest for Thu May 2: 2 on, 2 off"; got != want {
+		t.Errorf("subject %q, want %q", got, want)
+	}
+
+	text := d.Text()
+	for _, w := range []string{"Local Pub\n---------", "On tap:", "Pliny the Elder", "Gone:", "Dark Star"} {
+		if !strings.Contains(text, w) {
+			t.Errorf("text doesn't have %q:\n%s", w, text)
+		}
+	}
+
+	html, err := d.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{
+		"<title>Beer Digest</title>",
+		"<style>",
+		"On tap at Local Pub",
+		"Gone from Local Pub",
+		"Pliny the Elder",
+		"Generated using",
+	} {
+		if !strings.Contains(html, w) {
+			t.Errorf("HTML doesn't have %q:\n%s", w, html)
+		}
+	}
+	// The beers aren't linked, since the history page isn't in the mail.
+	if strings.Contains(html, "/beer?") {
+		t.Errorf("HTML links to beer history:\n%s", html)
+	}
+
+	empty := Summarize(testLog, at(20), at(24))
+	if !strings.Contains(empty.Text(), "Nothing changed.") {
+		t.Errorf("empty text digest doesn't say nothing changed:\n%s", empty.Text())
+	}
+	html, err = empty.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "Nothing changed") {
+		t.Errorf("empty HTML digest doesn't say nothing changed:\n%s", html)
+	}
+}
@@ -0,0 +1,203 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config describes who gets the digest, and how to send it.
+type Config struct {
+	SMTP SMTPConfig `json:"smtp"`
+	// At is the time of day to send the digest, like "07:30", in local
+	// time. It covers the day before.
+	At         string      `json:"at"`
+	Recipients []Recipient `json:"recipients"`
+	// SendEmpty sends the digest even when nothing changed.
+	SendEmpty bool `json:"send_empty,omitempty"`
+}
+
+// SMTPConfig is where to send mail from and through.
+type SMTPConfig struct {
+	// Addr is the server's host and port, like "smtp.example.com:587".
+	Addr string `json:"addr"`
+	From string `json:"from"`
+	// Username and Password are for PLAIN authentication, which is only
+	// attempted if Username is set. If Password isn't set, it's taken from
+	// the BEERWEB_SMTP_PASSWORD environment variable, to keep it out of the
+	// file.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Recipient is someone who gets the digest.
+type Recipient struct {
+	Email string `json:"email"`
+	// Venues limits the digest to these venues, by slug or by part of
+	// their names. The recipient gets every venue if it's empty.
+	Venues []string `json:"venues,omitempty"`
+}
+
+// DefaultAt is when the digest is sent if the config doesn't say.
+const DefaultAt = "07:00"
+
+// ReadConfig reads the digest configuration from a JSON file.
+func ReadConfig(filename string) (Config, error) {
+	var c Config
+	f, err := os.Open(filename)
+	if err != nil {
+		return c, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("reading digest config from %s: %v", filename, err)
+	}
+	if c.At == "" {
+		c.At = DefaultAt
+	}
+	if c.SMTP.Password == "" {
+		c.SMTP.Password = os.Getenv("BEERWEB_SMTP_PASSWORD")
+	}
+	if err := c.validate(); err != nil {
+		return c, fmt.Errorf("reading digest config from %s: %v", filename, err)
+	}
+	return c, nil
+}
+
+func (c Config) validate() error {
+	if _, _, err := net.SplitHostPort(c.SMTP.Addr); err != nil {
+		return fmt.Errorf("bad smtp addr: %v", err)
+	}
+	if c.SMTP.From == "" {
+		return fmt.Errorf("no smtp from address")
+	}
+	if _, err := time.Parse("15:04", c.At); err != nil {
+		return fmt.Errorf("bad time %q to send at; use HH:MM", c.At)
+	}
+	if len(c.Recipients) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+	for i, r := range c.Recipients {
+		if !strings.Contains(r.Email, "@") {
+			return fmt.Errorf("recipient %d has a bad email %q", i+1, r.Email)
+		}
+	}
+	return nil
+}
+
+// Next returns the first time after t that the digest should be sent.
+func (c Config) Next(t time.Time) time.Time {
+	at, err := time.Parse("15:04", c.At)
+	if err != nil {
+		at, _ = time.Parse("15:04", DefaultAt)
+	}
+	next := time.Date(t.Year(), t.Month(), t.Day(), at.Hour(), at.Minute(), 0, 0, t.Location())
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Send mails the digest to each recipient, with only the venues they're
+// interested in. A recipient whose venues didn't change gets nothing unless
+// SendEmpty is set. Every recipient is tried, and the first error is
+// returned.
+func (c Config) Send(d Digest) error {
+	var firstErr error
+	for _, r := range c.Recipients {
+		rd := d.ForVenues(r.Venues)
+		if rd.Empty() && !c.SendEmpty {
+			continue
+		}
+		if err := c.SMTP.Send(r.Email, rd); err != nil {
+			err = fmt.Errorf("sending digest to %s: %v", r.Email, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Send mails a digest to a single address, as both text and HTML.
+func (c SMTPConfig) Send(to string, d Digest) error {
+	html, err := d.HTML()
+	if err != nil {
+		return err
+	}
+	msg, err := message(c.From, to, d.Subject(), d.Text(), html)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if c.Username != "" {
+		host, _, _ := net.SplitHostPort(c.Addr)
+		auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+	return smtp.SendMail(c.Addr, auth, c.From, []string{to}, msg)
+}
+
+// message builds a multipart/alternative mail message.
+func message(from, to, subject, text, html string) ([]byte, error) {
+	var (
+		buf  bytes.Buffer
+		body bytes.Buffer
+		mw   = multipart.NewWriter(&body)
+	)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, h := range [][2]string{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + messageID() + "@beerweb>"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	} {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func messageID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,234 @@
+package digest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is just enough of an SMTP server to take mail from
+// smtp.SendMail. It refuses mail to anyone at reject.example.com.
+type smtpServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	messages map[string][]byte // recipient -> message
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln, messages: make(map[string][]byte)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	var (
+		tc = textproto.NewConn(conn)
+		to []string
+	)
+	tc.PrintfLine("220 localhost ready")
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			tc.PrintfLine("250 localhost")
+		case "MAIL", "RSET", "NOOP":
+			tc.PrintfLine("250 OK")
+		case "RCPT":
+			addr := strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			if strings.HasSuffix(addr, "@reject.example.com") {
+				tc.PrintfLine("550 no such user")
+				continue
+			}
+			to = append(to, addr)
+			tc.PrintfLine("250 OK")
+		case "DATA":
+			tc.PrintfLine("354 go ahead")
+			msg, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			for _, addr := range to {
+				s.messages[addr] = msg
+			}
+			s.mu.Unlock()
+			to = nil
+			tc.PrintfLine("250 OK")
+		case "QUIT":
+			tc.PrintfLine("221 bye")
+			return
+		default:
+			tc.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) message(t *testing.T, to string) *mail.Message {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.messages[to]
+	if !ok {
+		return nil
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// parts returns the decoded text of each part of a multipart message, by
+// content type.
+func parts(t *testing.T, msg *mail.Message) map[string]string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type %q", msg.Header.Get("Content-Type"))
+	}
+	found := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		body, err := ioutil.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		found[ct] = string(body)
+	}
+	return found
+}
+
+func TestSend(t *testing.T) {
+	srv := newSMTPServer(t)
+	d := Summarize(testLog, at(0), at(24))
+
+	tests := []struct {
+		name      string
+		recipient Recipient
+		sendEmpty bool
+		want      []string // venues in the digest, or nil for no mail
+	}{
+		{"every venue", Recipient{Email: "all@example.com"}, false, []string{"Local Pub", "Other Pub"}},
+		{"one venue", Recipient{Email: "local@example.com", Venues: []string{"local-pub"}}, false, []string{"Local Pub"}},
+		{"no changes", Recipient{Email: "none@example.com", Venues: []string{"nowhere"}}, false, nil},
+		{"no changes sent anyway", Recipient{Email: "empty@example.com", Venues: []string{"nowhere"}}, true, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{
+				SMTP:       SMTPConfig{Addr: srv.Addr(), From: "beerweb@example.com"},
+				Recipients: []Recipient{tt.recipient},
+				SendEmpty:  tt.sendEmpty,
+			}
+			if err := c.Send(d); err != nil {
+				t.Fatal(err)
+			}
+			msg := srv.message(t, tt.recipient.Email)
+			if tt.want == nil {
+				if msg != nil {
+					t.Fatal("got mail, want none")
+				}
+				return
+			}
+			if msg == nil {
+				t.Fatal("got no mail")
+			}
+			for h, want := range map[string]string{
+				"From":    "beerweb@example.com",
+				"To":      tt.recipient.Email,
+				"Subject": d.ForVenues(tt.recipient.Venues).Subject(),
+			} {
+				if got := msg.Header.Get(h); got != want {
+					t.Errorf("%s is %q, want %q", h, got, want)
+				}
+			}
+
+			ps := parts(t, msg)
+			text, html := ps["text/plain"], ps["text/html"]
+			if !strings.Contains(html, "<title>Beer Digest</title>") {
+				t.Errorf("no HTML part:\n%s", html)
+			}
+			for _, venue := range []string{"Local Pub", "Other Pub"} {
+				want := false
+				for _, v := range tt.want {
+					want = want || v == venue
+				}
+				if strings.Contains(text, venue) != want || strings.Contains(html, "at "+venue) != want {
+					t.Errorf("digest has %s: %v, want %v\n%s", venue, !want, want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestSendFailure(t *testing.T) {
+	srv := newSMTPServer(t)
+	c := Config{
+		SMTP: SMTPConfig{Addr: srv.Addr(), From: "beerweb@example.com"},
+		Recipients: []Recipient{
+			{Email: "nobody@reject.example.com"},
+			{Email: "somebody@example.com"},
+		},
+	}
+	err := c.Send(Summarize(testLog, at(0), at(24)))
+	if err == nil || !strings.Contains(err.Error(), "nobody@reject.example.com") {
+		t.Errorf("got error %v, want one about the rejected recipient", err)
+	}
+	// The rest still get their mail.
+	if srv.message(t, "somebody@example.com") == nil {
+		t.Error("second recipient got no mail")
+	}
+}
+
+func TestNext(t *testing.T) {
+	c := Config{At: "07:30"}
+	tests := []struct {
+		now, want time.Time
+	}{
+		{time.Date(2019, 5, 1, 6, 0, 0, 0, time.UTC), time.Date(2019, 5, 1, 7, 30, 0, 0, time.UTC)},
+		{time.Date(2019, 5, 1, 7, 30, 0, 0, time.UTC), time.Date(2019, 5, 2, 7, 30, 0, 0, time.UTC)},
+		{time.Date(2019, 5, 31, 22, 0, 0, 0, time.UTC), time.Date(2019, 6, 1, 7, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := c.Next(tt.now); !got.Equal(tt.want) {
+			t.Errorf("Next(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
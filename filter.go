@@ -0,0 +1,80 @@
+package beerweb
+
+import "strings"
+
+// Filter selects beers by their attributes. Text fields match if they're
+// contained in the corresponding field of a beer, disregarding case, and
+// fields left empty or zero match everything.
+type Filter struct {
+	// Venue matches a venue by slug, or by part of its name.
+	Venue   string
+	Brewery string
+	Style   string
+	Origin  string
+	// MinABV and MaxABV bound the strength of a beer, in percent. Beers
+	// whose ABV isn't known don't match if either bound is set.
+	MinABV float64
+	MaxABV float64
+	// Query is free text that is searched for in the name and brewery of a
+	// beer. Every word in it needs to be found for the beer to match.
+	Query string
+}
+
+// Empty reports whether the filter matches everything.
+func (f Filter) Empty() bool {
+	return f == Filter{}
+}
+
+// MatchVenue reports whether a venue is selected by the filter.
+func (f Filter) MatchVenue(venue string) bool {
+	return f.Venue == "" || Slug(venue) == f.Venue || containsFold(venue, f.Venue)
+}
+
+// Match reports whether a beer is selected by the filter. The venue isn't
+// considered; see MatchVenue.
+func (f Filter) Match(b Beer) bool {
+	if !containsFold(b.Brewery, f.Brewery) ||
+		!containsFold(b.Style, f.Style) ||
+		!containsFold(b.Origin, f.Origin) {
+		return false
+	}
+	if f.MinABV > 0 || f.MaxABV > 0 {
+		if !b.ABV.Known() {
+			return false
+		}
+		abv := b.ABV.Value()
+		if f.MinABV > 0 && abv < f.MinABV {
+			return false
+		}
+		if f.MaxABV > 0 && abv > f.MaxABV {
+			return false
+		}
+	}
+	text := b.Brewery + " " + b.Name
+	for _, word := range strings.Fields(f.Query) {
+		if !containsFold(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns a copy of the taplist with only the beers that match the
+// filter.
+func (f Filter) Apply(tl Taplist) Taplist {
+	if f.Empty() {
+		return tl
+	}
+	beers := make([]Beer, 0, len(tl.Beers))
+	for _, b := range tl.Beers {
+		if f.Match(b) {
+			beers = append(beers, b)
+		}
+	}
+	tl.Beers = beers
+	return tl
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,75 @@
+package beerweb
+
+import "testing"
+
+func TestFilterMatch(t *testing.T) {
+	var (
+		lush  = Beer{Brewery: "Fremont", Name: "Lush", Style: "IPA", Origin: "Seattle, WA", ABV: ParseABV("7%")}
+		pliny = Beer{Brewery: "Russian River", Name: "Pliny the Elder", Style: "Double IPA", ABV: ParseABV("8")}
+		cider = Beer{Brewery: "Schilling", Name: "Excelsior", Style: "Cider"}
+	)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []bool // whether lush, pliny and cider match
+	}{
+		{"empty", Filter{}, []bool{true, true, true}},
+		{"query in the name", Filter{Query: "elder"}, []bool{false, true, false}},
+		{"query in the brewery", Filter{Query: "FREMONT"}, []bool{true, false, false}},
+		{"query with every word", Filter{Query: "russian pliny"}, []bool{false, true, false}},
+		{"query missing a word", Filter{Query: "russian lush"}, []bool{false, false, false}},
+		{"query not in the style", Filter{Query: "ipa"}, []bool{false, false, false}},
+		{"style", Filter{Style: "ipa"}, []bool{true, true, false}},
+		{"brewery", Filter{Brewery: "river"}, []bool{false, true, false}},
+		{"origin", Filter{Origin: "seattle"}, []bool{true, false, false}},
+		{"min ABV", Filter{MinABV: 7.5}, []bool{false, true, false}},
+		{"min ABV inclusive", Filter{MinABV: 7}, []bool{true, true, false}},
+		{"max ABV", Filter{MaxABV: 7}, []bool{true, false, false}},
+		{"ABV range", Filter{MinABV: 6, MaxABV: 7.5}, []bool{true, false, false}},
+		{"style and ABV", Filter{Style: "ipa", MinABV: 7.5}, []bool{false, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, b := range []Beer{lush, pliny, cider} {
+				if got := tt.filter.Match(b); got != tt.want[i] {
+					t.Errorf("Match(%s) = %v, want %v", b.Name, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilterMatchVenue(t *testing.T) {
+	tests := []struct {
+		venue string
+		want  bool
+	}{
+		{"Chuck's Hop Shop (Greenwood)", true},
+		{"Chuck's Hop Shop (CD)", false},
+		{"Greenwood Pub", false},
+	}
+	for _, filter := range []Filter{{Venue: "chucks-hop-shop-greenwood"}, {Venue: "shop (greenwood"}} {
+		for _, tt := range tests {
+			if got := filter.MatchVenue(tt.venue); got != tt.want {
+				t.Errorf("%q MatchVenue(%q) = %v, want %v", filter.Venue, tt.venue, got, tt.want)
+			}
+		}
+	}
+	if !(Filter{}).MatchVenue("Anywhere") {
+		t.Error("empty filter doesn't match every venue")
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	tl := Taplist{Venue: "Pub", Beers: []Beer{
+		{Brewery: "Fremont", Name: "Lush"},
+		{Brewery: "Russian River", Name: "Pliny the Elder"},
+	}}
+	got := Filter{Brewery: "fremont"}.Apply(tl)
+	if got.Venue != "Pub" || len(got.Beers) != 1 || got.Beers[0].Name != "Lush" {
+		t.Errorf("got %+v", got)
+	}
+	if len(tl.Beers) != 2 {
+		t.Errorf("the original taplist was changed: %+v", tl)
+	}
+}
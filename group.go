@@ -0,0 +1,148 @@
+package beerweb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Group is a set of beers that have something in common, like their
+// brewery, still listed by venue.
+type Group struct {
+	// Name is the value the beers have in common. It's empty for the group
+	// of beers that don't have one.
+	Name     string
+	Taplists []Taplist
+}
+
+// Beers returns the number of beers in the group.
+func (g Group) Beers() int {
+	var n int
+	for _, tl := range g.Taplists {
+		n += len(tl.Beers)
+	}
+	return n
+}
+
+// GroupBy sorts the beers of the taplists into groups by a field, like
+// "brewery" or "style", or by "venue". Groups are sorted by name, with the
+// group of beers missing the field last. Values of the field that differ
+// only in case are put in the same group, named for the first seen.
+func GroupBy(taplists []Taplist, by string) ([]Group, error) {
+	by = strings.ToLower(by)
+	if by == "venue" {
+		groups := make([]Group, len(taplists))
+		for i, tl := range taplists {
+			groups[i] = Group{Name: tl.Venue, Taplists: []Taplist{tl}}
+		}
+		sortGroups(groups)
+		return groups, nil
+	}
+
+	value, ok := field(by)
+	if !ok {
+		return nil, fmt.Errorf("can't group by %q (fields are venue, %s)", by, strings.Join(fieldNames(), ", "))
+	}
+	var (
+		groups []Group
+		index  = make(map[string]int)
+	)
+	for _, tl := range taplists {
+		// Each group gets at most one taplist for the venue, built up as
+		// its beers are found.
+		venueIndex := make(map[int]int)
+		for _, b := range tl.Beers {
+			name := value(b)
+			key := strings.ToLower(name)
+			gi, ok := index[key]
+			if !ok {
+				gi = len(groups)
+				index[key] = gi
+				groups = append(groups, Group{Name: name})
+			}
+			g := &groups[gi]
+			ti, ok := venueIndex[gi]
+			if !ok {
+				ti = len(g.Taplists)
+				venueIndex[gi] = ti
+				g.Taplists = append(g.Taplists, Taplist{Venue: tl.Venue, URL: tl.URL})
+			}
+			g.Taplists[ti].Beers = append(g.Taplists[ti].Beers, b)
+		}
+	}
+	sortGroups(groups)
+	return groups, nil
+}
+
+func sortGroups(groups []Group) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Name, groups[j].Name
+		if a == "" || b == "" {
+			return b == ""
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+}
+
+// Summary counts what's on a set of taplists.
+type Summary struct {
+	Venues    int
+	Beers     int
+	Breweries int
+	Styles    int
+	// MeanABV is the average ABV of the beers whose ABV is known, and ABVs
+	// is how many of them there are.
+	MeanABV float64
+	ABVs    int
+}
+
+// Summarize counts the beers on the taplists. Breweries and styles that
+// differ only in case are counted once.
+func Summarize(taplists []Taplist) Summary {
+	var (
+		s         = Summary{Venues: len(taplists)}
+		breweries = make(map[string]bool)
+		styles    = make(map[string]bool)
+		totalABV  float64
+	)
+	for _, tl := range taplists {
+		for _, b := range tl.Beers {
+			s.Beers++
+			breweries[strings.ToLower(b.Brewery)] = true
+			if b.Style != "" {
+				styles[strings.ToLower(b.Style)] = true
+			}
+			if b.ABV.Known() {
+				s.ABVs++
+				totalABV += b.ABV.Value()
+			}
+		}
+	}
+	s.Breweries, s.Styles = len(breweries), len(styles)
+	if s.ABVs > 0 {
+		s.MeanABV = totalABV / float64(s.ABVs)
+	}
+	return s
+}
+
+// String describes the summary in a line, like "42 beers from 30 breweries
+// in 12 styles at 3 venues, averaging 6.4% ABV".
+func (s Summary) String() string {
+	str := fmt.Sprintf("%s from %s in %s at %s",
+		plural(s.Beers, "beer"), plural(s.Breweries, "brewery"),
+		plural(s.Styles, "style"), plural(s.Venues, "venue"))
+	if s.ABVs > 0 {
+		str += fmt.Sprintf(", averaging %.1f%% ABV", s.MeanABV)
+	}
+	return str
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		noun = noun[:len(noun)-1] + "ie"
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
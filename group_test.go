@@ -0,0 +1,120 @@
+package beerweb
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var groupTaplists = []Taplist{
+	{Venue: "Pub", Beers: []Beer{
+		{Brewery: "Fremont", Name: "Lush", Style: "IPA", ABV: ParseABV("7%")},
+		{Brewery: "Russian River", Name: "Pliny the Elder", Style: "DIPA", ABV: ParseABV("8%")},
+		{Brewery: "fremont", Name: "Dark Star", Style: "Stout"},
+	}},
+	{Venue: "Bar", Beers: []Beer{
+		{Brewery: "Fremont", Name: "Lush", Style: "ipa", ABV: ParseABV("7%")},
+		{Brewery: "Schilling", Name: "Excelsior", ABV: ParseABV("6.5")},
+	}},
+	{Venue: "Empty"},
+}
+
+func TestGroupBy(t *testing.T) {
+	// group describes a group as its name and the venue and name of
+	// each of its beers.
+	type group struct {
+		name  string
+		beers []string
+	}
+	tests := []struct {
+		by      string
+		want    []group
+		wantErr bool
+	}{
+		{"brewery", []group{
+			{"Fremont", []string{"Pub: Lush", "Pub: Dark Star", "Bar: Lush"}},
+			{"Russian River", []string{"Pub: Pliny the Elder"}},
+			{"Schilling", []string{"Bar: Excelsior"}},
+		}, false},
+		{"Style", []group{
+			{"DIPA", []string{"Pub: Pliny the Elder"}},
+			{"IPA", []string{"Pub: Lush", "Bar: Lush"}},
+			{"Stout", []string{"Pub: Dark Star"}},
+			{"", []string{"Bar: Excelsior"}},
+		}, false},
+		{"venue", []group{
+			{"Bar", []string{"Bar: Lush", "Bar: Excelsior"}},
+			{"Empty", nil},
+			{"Pub", []string{"Pub: Lush", "Pub: Pliny the Elder", "Pub: Dark Star"}},
+		}, false},
+		{"color", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			groups, err := GroupBy(groupTaplists, tt.by)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "can't group by") {
+					t.Errorf("got error %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []group
+			for _, g := range groups {
+				gr := group{name: g.Name}
+				for _, tl := range g.Taplists {
+					for _, b := range tl.Beers {
+						gr.beers = append(gr.beers, tl.Venue+": "+b.Name)
+					}
+				}
+				if g.Beers() != len(gr.beers) {
+					t.Errorf("group %q says it has %d beers, but has %d", g.Name, g.Beers(), len(gr.beers))
+				}
+				got = append(got, gr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name     string
+		taplists []Taplist
+		want     Summary
+		str      string
+	}{
+		{"nothing", nil, Summary{}, "0 beers from 0 breweries in 0 styles at 0 venues"},
+		{
+			"one of each",
+			[]Taplist{{Venue: "Pub", Beers: []Beer{{Brewery: "Fremont", Name: "Lush", Style: "IPA"}}}},
+			Summary{Venues: 1, Beers: 1, Breweries: 1, Styles: 1},
+			"1 beer from 1 brewery in 1 style at 1 venue",
+		},
+		{
+			"several",
+			groupTaplists,
+			Summary{Venues: 3, Beers: 5, Breweries: 3, Styles: 3, MeanABV: 7.125, ABVs: 4},
+			"5 beers from 3 breweries in 3 styles at 3 venues, averaging 7.1% ABV",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Summarize(tt.taplists)
+			if math.Abs(got.MeanABV-tt.want.MeanABV) < 1e-9 {
+				got.MeanABV = tt.want.MeanABV
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if s := got.String(); s != tt.str {
+				t.Errorf("got %q, want %q", s, tt.str)
+			}
+		})
+	}
+}
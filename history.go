@@ -0,0 +1,213 @@
+package beerweb
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TaplistChange is an entry in a ChangeLog, recording how a venue's taplist
+// changed at a particular time.
+type TaplistChange struct {
+	Time time.Time `json:"time"`
+	TaplistDiff
+}
+
+// ChangeLog keeps a record of how taplists have changed over time, which is
+// much more compact than a Store of snapshots, since nothing is recorded
+// while a taplist stays the same. See package store for implementations.
+type ChangeLog interface {
+	// Append adds a change to the end of the log.
+	Append(TaplistChange) error
+	// Changes returns every change in the log, oldest first.
+	Changes() ([]TaplistChange, error)
+	// Close releases any resources held by the log.
+	Close() error
+}
+
+// Stint is a period that a beer was on tap at a venue, which usually
+// corresponds to a single keg.
+type Stint struct {
+	Venue string    `json:"venue"`
+	Start time.Time `json:"start"`
+	// End is when the beer was found to be gone, or zero if it's still
+	// on tap.
+	End time.Time `json:"end,omitempty"`
+	// Beer is the beer as it was last listed.
+	Beer Beer `json:"beer"`
+}
+
+// OnTap reports whether the beer is still on tap.
+func (s Stint) OnTap() bool {
+	return s.End.IsZero()
+}
+
+// Duration returns how long the beer was on tap, or has been so far, as of
+// now.
+func (s Stint) Duration(now time.Time) time.Duration {
+	if s.OnTap() {
+		return now.Sub(s.Start)
+	}
+	return s.End.Sub(s.Start)
+}
+
+// BeerHistory describes everywhere a beer has been on tap.
+type BeerHistory struct {
+	Brewery string  `json:"brewery"`
+	Name    string  `json:"name"`
+	Stints  []Stint `json:"stints"`
+}
+
+// FirstSeen returns when the beer first went on tap anywhere, or zero if it
+// never has.
+func (h BeerHistory) FirstSeen() time.Time {
+	if len(h.Stints) == 0 {
+		return time.Time{}
+	}
+	return h.Stints[0].Start
+}
+
+// LastSeen returns the last time the beer was known to be on tap anywhere,
+// which is now if it's still on tap.
+func (h BeerHistory) LastSeen(now time.Time) time.Time {
+	var last time.Time
+	for _, s := range h.Stints {
+		if s.OnTap() {
+			return now
+		}
+		if s.End.After(last) {
+			last = s.End
+		}
+	}
+	return last
+}
+
+// OnTap returns the stints that are still going.
+func (h BeerHistory) OnTap() []Stint {
+	var stints []Stint
+	for _, s := range h.Stints {
+		if s.OnTap() {
+			stints = append(stints, s)
+		}
+	}
+	return stints
+}
+
+// FindBeer works out from a change log when and where a beer has been on
+// tap. The brewery and name are matched the same way as Beer.Key. A venue
+// can list a beer more than once, on nitro as well as regular draft, say,
+// and its stint there lasts until the last of its listings is gone.
+func FindBeer(changes []TaplistChange, brewery, name string) BeerHistory {
+	var (
+		h        = BeerHistory{Brewery: brewery, Name: name}
+		key      = Beer{Brewery: brewery, Name: name}.Key()
+		taplists = make(map[string]*Taplist) // venue -> taplist as of the change
+		open     = make(map[string]int)      // venue -> index of stint
+	)
+	for _, c := range changes {
+		tl, ok := taplists[c.Venue]
+		if !ok {
+			tl = &Taplist{Venue: c.Venue}
+			taplists[c.Venue] = tl
+		}
+		tl.apply(c.TaplistDiff)
+
+		var listed []Beer
+		for _, b := range tl.Beers {
+			if b.Key() == key {
+				listed = append(listed, b)
+			}
+		}
+		i, ok := open[c.Venue]
+		switch {
+		case len(listed) > 0 && !ok:
+			open[c.Venue] = len(h.Stints)
+			h.Stints = append(h.Stints, Stint{Venue: c.Venue, Start: c.Time, Beer: listed[0]})
+		case len(listed) > 0:
+			h.Stints[i].Beer = listed[0]
+		case ok:
+			h.Stints[i].End = c.Time
+			delete(open, c.Venue)
+		}
+	}
+	if len(h.Stints) > 0 {
+		// Use the names as they were listed, rather than as they were
+		// asked for.
+		last := h.Stints[len(h.Stints)-1].Beer
+		h.Brewery, h.Name = last.Brewery, last.Name
+	}
+	return h
+}
+
+// Replay rebuilds a snapshot of each venue's taplist as of the last change
+// to it in a log. Beers are listed in the order they were added.
+func Replay(changes []TaplistChange) []Snapshot {
+	var (
+		snaps []Snapshot
+		index = make(map[string]int) // venue -> index of snapshot
+	)
+	for _, c := range changes {
+		i, ok := index[c.Venue]
+		if !ok {
+			i = len(snaps)
+			index[c.Venue] = i
+			snaps = append(snaps, Snapshot{Taplist: Taplist{Venue: c.Venue}})
+		}
+		snaps[i].Time = c.Time
+		snaps[i].Taplist.apply(c.TaplistDiff)
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].Taplist.Venue < snaps[j].Taplist.Venue
+	})
+	return snaps
+}
+
+// apply makes the changes in d to the taplist. Listings are identified by
+// their ListingKeys, as Diff does, so that when a beer is listed twice, it's
+// the right one that's removed or changed. Added beers go on the end.
+func (tl *Taplist) apply(d TaplistDiff) {
+	_, removed := d.Keys(tl.Beers)
+	gone := make(map[string]bool, len(removed))
+	for _, key := range removed {
+		gone[key] = true
+	}
+	changed := make(map[string]Beer, len(d.Changed))
+	for _, c := range d.Changed {
+		key := c.Key
+		if key == "" {
+			// Logged before changes said which listing it was.
+			key = c.Old.Key()
+		}
+		changed[key] = c.New
+	}
+
+	keys := ListingKeys(tl.Beers)
+	beers := tl.Beers[:0]
+	for i, b := range tl.Beers {
+		if gone[keys[i]] {
+			continue
+		}
+		if nb, ok := changed[keys[i]]; ok {
+			b = nb
+		}
+		beers = append(beers, b)
+	}
+	tl.Beers = append(beers, d.Added...)
+}
+
+// HumanDuration formats a duration roughly, like "3d 4h", "5h 10m" or
+// "12m", which is plenty for how long a keg lasted.
+func HumanDuration(d time.Duration) string {
+	var (
+		days  = int(d / (24 * time.Hour))
+		hours = int(d % (24 * time.Hour) / time.Hour)
+		mins  = int(d % time.Hour / time.Minute)
+	)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
@@ -0,0 +1,218 @@
+package beerweb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	histStart = time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	draft = Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "draft"}
+	nitro = Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "nitro"}
+	lush  = Beer{Brewery: "Fremont", Name: "Lush"}
+	pliny = Beer{Brewery: "Russian River", Name: "Pliny the Elder"}
+)
+
+func hour(n int) time.Time {
+	return histStart.Add(time.Duration(n) * time.Hour)
+}
+
+// logTaplists makes a change log out of a venue's successive taplists, the
+// way the server does, fetching one each hour.
+func logTaplists(venue string, taplists ...[]Beer) []TaplistChange {
+	var (
+		changes []TaplistChange
+		prev    = Taplist{Venue: venue}
+	)
+	for i, beers := range taplists {
+		next := Taplist{Venue: venue, Beers: beers}
+		if d := Diff(prev, next); !d.Empty() {
+			changes = append(changes, TaplistChange{Time: hour(i), TaplistDiff: d})
+		}
+		prev = next
+	}
+	return changes
+}
+
+func TestFindBeer(t *testing.T) {
+	type stint struct {
+		venue      string
+		start, end int // end is -1 if it's still on tap
+		serving    string
+	}
+	tests := []struct {
+		name    string
+		changes []TaplistChange
+		want    []stint
+	}{
+		{"never on tap", logTaplists("Pub", []Beer{lush}, []Beer{pliny}), nil},
+		{"still on tap", logTaplists("Pub", []Beer{lush}, []Beer{lush, draft}), []stint{{"Pub", 1, -1, "draft"}}},
+		{
+			"gone and back again",
+			logTaplists("Pub", []Beer{draft}, []Beer{lush}, []Beer{lush}, []Beer{draft, lush}),
+			[]stint{{"Pub", 0, 1, "draft"}, {"Pub", 3, -1, "draft"}},
+		},
+		{
+			"one listing of two gone",
+			logTaplists("Pub", []Beer{draft, nitro}, []Beer{draft}, []Beer{draft}),
+			[]stint{{"Pub", 0, -1, "draft"}},
+		},
+		{
+			"first listing of two gone",
+			logTaplists("Pub", []Beer{draft, lush, nitro}, []Beer{lush, nitro}),
+			[]stint{{"Pub", 0, -1, "nitro"}},
+		},
+		{
+			"both listings gone, one at a time",
+			logTaplists("Pub", []Beer{draft, nitro}, []Beer{nitro}, []Beer{lush}),
+			[]stint{{"Pub", 0, 2, "nitro"}},
+		},
+		{
+			"second listing added later",
+			logTaplists("Pub", []Beer{draft}, []Beer{draft, nitro}, []Beer{nitro}, nil),
+			[]stint{{"Pub", 0, 3, "nitro"}},
+		},
+		{
+			"at two venues",
+			append(logTaplists("Pub", []Beer{draft}, nil), logTaplists("Bar", nil, []Beer{nitro})...),
+			[]stint{{"Pub", 0, 1, "draft"}, {"Bar", 1, -1, "nitro"}},
+		},
+		{
+			// A poller that restarted without knowing the taplist
+			// adds everything again, which doesn't start a new stint.
+			"added again after a restart",
+			append(logTaplists("Pub", []Beer{draft}), TaplistChange{
+				Time:        hour(5),
+				TaplistDiff: TaplistDiff{Venue: "Pub", Added: []Beer{draft}},
+			}),
+			[]stint{{"Pub", 0, -1, "draft"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := FindBeer(tt.changes, "fremont", "DARK STAR")
+			var got []stint
+			for _, s := range h.Stints {
+				st := stint{s.Venue, int(s.Start.Sub(histStart) / time.Hour), -1, s.Beer.Serving}
+				if !s.OnTap() {
+					st.end = int(s.End.Sub(histStart) / time.Hour)
+				}
+				got = append(got, st)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got stints %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBeerChanged(t *testing.T) {
+	styled := draft
+	styled.Style = "Imperial Stout"
+	h := FindBeer(logTaplists("Pub", []Beer{draft}, []Beer{styled}), "Fremont", "Dark Star")
+	if len(h.Stints) != 1 || h.Stints[0].Beer.Style != "Imperial Stout" {
+		t.Errorf("got %+v, want one stint with the new style", h.Stints)
+	}
+
+	h = FindBeer(logTaplists("Pub", []Beer{draft}, nil), " fremont ", "dark  star")
+	if h.Brewery != "Fremont" || h.Name != "Dark Star" {
+		t.Errorf("got %q %q, want the names as they were listed", h.Brewery, h.Name)
+	}
+	now := hour(10)
+	if first, last := h.FirstSeen(), h.LastSeen(now); !first.Equal(hour(0)) || !last.Equal(hour(1)) {
+		t.Errorf("first seen %v, last seen %v", first, last)
+	}
+	if d := h.Stints[0].Duration(now); d != time.Hour {
+		t.Errorf("lasted %v, want an hour", d)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	styled := nitro
+	styled.Style = "Stout"
+
+	tests := []struct {
+		name     string
+		taplists [][]Beer
+		want     []Beer
+	}{
+		{"added", [][]Beer{{lush}, {lush, pliny}}, []Beer{lush, pliny}},
+		{"removed", [][]Beer{{lush, pliny}, {pliny}}, []Beer{pliny}},
+		{"everything gone", [][]Beer{{lush, pliny}, nil}, nil},
+		{"changed", [][]Beer{{lush, nitro}, {lush, styled}}, []Beer{lush, styled}},
+		{"second listing removed", [][]Beer{{draft, lush, nitro}, {draft, lush}}, []Beer{draft, lush}},
+		{"second listing changed", [][]Beer{{draft, nitro}, {draft, styled}}, []Beer{draft, styled}},
+		{
+			// Listings are matched in order, so the first one changes
+			// into the second and the second goes.
+			"first listing removed",
+			[][]Beer{{draft, lush, nitro}, {lush, nitro}},
+			[]Beer{nitro, lush},
+		},
+		{"listing added again", [][]Beer{{draft, nitro}, {draft}, {draft, styled}}, []Beer{draft, styled}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snaps := Replay(logTaplists("Pub", tt.taplists...))
+			if len(snaps) != 1 {
+				t.Fatalf("got %d snapshots, want 1", len(snaps))
+			}
+			if got := snaps[0].Taplist.Beers; len(got) != len(tt.want) || len(got) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			// Diffing against the replayed taplist has to find nothing
+			// more than a change of order.
+			last := Taplist{Venue: "Pub", Beers: tt.taplists[len(tt.taplists)-1]}
+			if d := Diff(snaps[0].Taplist, last); !d.Empty() {
+				t.Errorf("replayed taplist differs from the last one fetched: %v", d)
+			}
+		})
+	}
+}
+
+func TestReplayVenues(t *testing.T) {
+	changes := append(
+		logTaplists("Pub", []Beer{lush}),
+		logTaplists("Bar", nil, []Beer{pliny}, []Beer{pliny, draft})...,
+	)
+	snaps := Replay(changes)
+	if len(snaps) != 2 {
+		t.Fatalf("got %d snapshots, want 2", len(snaps))
+	}
+	for i, want := range []struct {
+		venue string
+		time  time.Time
+		beers int
+	}{
+		{"Bar", hour(2), 2},
+		{"Pub", hour(0), 1},
+	} {
+		s := snaps[i]
+		if s.Taplist.Venue != want.venue || !s.Time.Equal(want.time) || len(s.Taplist.Beers) != want.beers {
+			t.Errorf("snapshot %d is %s at %v with %d beers, want %s at %v with %d",
+				i, s.Taplist.Venue, s.Time, len(s.Taplist.Beers), want.venue, want.time, want.beers)
+		}
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0m"},
+		{59 * time.Second, "0m"},
+		{12 * time.Minute, "12m"},
+		{time.Hour, "1h 0m"},
+		{5*time.Hour + 10*time.Minute, "5h 10m"},
+		{24 * time.Hour, "1d 0h"},
+		{3*24*time.Hour + 4*time.Hour + 30*time.Minute, "3d 4h"},
+	}
+	for _, tt := range tests {
+		if got := HumanDuration(tt.d); got != tt.want {
+			t.Errorf("HumanDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
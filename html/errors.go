@@ -0,0 +1,43 @@
+package html
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError is returned when a venue's page responds with an HTTP status
+// other than 200, 201 or 202, which are the only ones with a page to scrape.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// LookupError is returned when a venue's host name can't be resolved.
+type LookupError struct {
+	Host string
+	Err  error
+}
+
+func (e *LookupError) Error() string {
+	return fmt.Sprintf("can't resolve %s: %v", e.Host, e.Err)
+}
+
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
+// NoMatchError is returned when a page is fetched successfully but the table
+// selector doesn't match anything in it, which usually means the venue has
+// changed its site layout.
+type NoMatchError struct {
+	URL      string
+	Selector string
+}
+
+func (e *NoMatchError) Error() string {
+	return fmt.Sprintf("table selector %q matched nothing at %s", e.Selector, e.URL)
+}
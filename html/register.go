@@ -0,0 +1,84 @@
+package html
+
+import (
+	"errors"
+
+	"github.com/gocolly/colly"
+	"github.com/ianfoo/beerweb"
+)
+
+// FetcherKind is the type to give venues that are scraped by Taplist.
+const FetcherKind = "html-table"
+
+// collector is shared by all venues built through the fetcher registry.
+var collector = colly.NewCollector()
+
+func init() {
+	beerweb.RegisterFetcher(FetcherKind, newFromConfig)
+}
+
+// tableOptions are the options understood by the html-table fetcher, like
+//
+//	"selectors": {
+//	  "table": "div[id=draft_list] > table",
+//	  "brewery": "td.draft_brewery",
+//	  "name": "td.draft_name",
+//	  "prices": [{"size": "pint", "selector": "td.draft_pint"}]
+//	}
+type tableOptions struct {
+	Selectors struct {
+		Table   string `json:"table"`
+		Brewery string `json:"brewery"`
+		Name    string `json:"name"`
+		Style   string `json:"style"`
+		Origin  string `json:"origin"`
+		ABV     string `json:"abv"`
+		IBU     string `json:"ibu"`
+		Tap     string `json:"tap"`
+		Serving string `json:"serving"`
+		Prices  []struct {
+			Size     string `json:"size"`
+			Selector string `json:"selector"`
+		} `json:"prices"`
+	} `json:"selectors"`
+}
+
+func newFromConfig(vc beerweb.VenueConfig) (beerweb.Taplister, error) {
+	var opts tableOptions
+	if err := vc.Options.Decode(&opts); err != nil {
+		return nil, err
+	}
+	sel := opts.Selectors
+	switch {
+	case sel.Table == "":
+		return nil, errors.New("missing table selector")
+	case sel.Brewery == "":
+		return nil, errors.New("missing brewery selector")
+	case sel.Name == "":
+		return nil, errors.New("missing name selector")
+	}
+
+	c := TaplistConfig{
+		Venue:           vc.Name,
+		URL:             vc.URL,
+		TableSelector:   sel.Table,
+		BrewerySelector: sel.Brewery,
+		NameSelector:    sel.Name,
+		StyleSelector:   sel.Style,
+		OriginSelector:  sel.Origin,
+		ABVSelector:     sel.ABV,
+		IBUSelector:     sel.IBU,
+		TapSelector:     sel.Tap,
+		ServingSelector: sel.Serving,
+	}
+	for _, p := range sel.Prices {
+		if p.Selector == "" {
+			return nil, errors.New("missing selector for price")
+		}
+		c.PriceSelectors = append(c.PriceSelectors, PriceSelector{
+			Size:     p.Size,
+			Selector: p.Selector,
+		})
+	}
+	return NewTaplist(collector, c), nil
+}
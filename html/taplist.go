@@ -1,15 +1,20 @@
 package html
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+
 	"github.com/gocolly/colly"
 	"github.com/ianfoo/beerweb"
 )
 
+// Taplist scrapes a venue's beers from an HTML table. It holds no state
+// between fetches, so FetchBeers is safe to call concurrently.
 type Taplist struct {
-	venue string
-	url   string
-	Beers []beerweb.Beer
-
+	config    TaplistConfig
 	collector *colly.Collector
 }
 
@@ -22,46 +27,145 @@ type TaplistConfig struct {
 	StyleSelector   string
 	OriginSelector  string
 	ABVSelector     string
+	IBUSelector     string
+	TapSelector     string
+	ServingSelector string
+	PriceSelectors  []PriceSelector
 }
 
-// TODO Actually return errors
-func (tl *Taplist) FetchBeers() ([]beerweb.Beer, error) {
-	tl.collector.Visit(tl.url)
-	return tl.Beers, nil
+// PriceSelector finds the price of one serving size of a beer within its row.
+type PriceSelector struct {
+	Size     string
+	Selector string
+}
+
+// FetchBeers scrapes the venue's page and returns the beers found in it.
+// Failures are reported as a *StatusError, *LookupError or *NoMatchError
+// where possible, and as ctx.Err() if the context ended first.
+func (tl *Taplist) FetchBeers(ctx context.Context) ([]beerweb.Beer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id, release := transport.register(ctx)
+	defer release()
+
+	var (
+		c = tl.collector.Clone()
+
+		mu        sync.Mutex
+		beers     []beerweb.Beer
+		matched   bool
+		scrapeErr error
+	)
+	// The visited URL store is shared between clones, so without this only
+	// the first fetch would ever make a request.
+	c.AllowURLRevisit = true
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set(fetchIDHeader, id)
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if scrapeErr == nil {
+			scrapeErr = tl.classify(r, err)
+		}
+	})
+	c.OnHTML(tl.config.TableSelector, func(table *colly.HTMLElement) {
+		rowBeers := tl.scrapeTable(table)
+		mu.Lock()
+		defer mu.Unlock()
+		matched = true
+		beers = append(beers, rowBeers...)
+	})
+
+	// The collector isn't Async, so Visit is done when it returns. Waiting
+	// on the collector would be a mistake: clones share their parent's wait
+	// group, so it would wait on every other venue's fetch too.
+	err := c.Visit(tl.config.URL)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if scrapeErr != nil {
+		return nil, scrapeErr
+	}
+	if err != nil {
+		return nil, tl.classify(nil, err)
+	}
+	if !matched {
+		return nil, &NoMatchError{URL: tl.config.URL, Selector: tl.config.TableSelector}
+	}
+	return beers, nil
+}
+
+func (tl *Taplist) scrapeTable(table *colly.HTMLElement) []beerweb.Beer {
+	var (
+		c     = tl.config
+		beers []beerweb.Beer
+	)
+	table.ForEach("tr", func(_ int, row *colly.HTMLElement) {
+		beer := beerweb.Beer{
+			Brewery: row.ChildText(c.BrewerySelector),
+			Name:    row.ChildText(c.NameSelector),
+			Style:   row.ChildText(c.StyleSelector),
+			Origin:  row.ChildText(c.OriginSelector),
+			ABV:     beerweb.ParseABV(row.ChildText(c.ABVSelector)),
+			IBU:     row.ChildText(c.IBUSelector),
+			Tap:     row.ChildText(c.TapSelector),
+			Serving: row.ChildText(c.ServingSelector),
+		}
+		for _, ps := range c.PriceSelectors {
+			if amount := row.ChildText(ps.Selector); amount != "" {
+				beer.Prices = append(beer.Prices, beerweb.Price{Size: ps.Size, Amount: amount})
+			}
+		}
+		if beer.Valid() {
+			beers = append(beers, beer)
+		}
+	})
+	return beers
+}
+
+// classify turns an error reported by colly into one of the package's error
+// types, if it's something we know how to describe. Colly only parses pages
+// that come back 200, 201 or 202, and reports any other status as an error,
+// including successes like 204 No Content, which have no beers either.
+func (tl *Taplist) classify(r *colly.Response, err error) error {
+	if r != nil && r.StatusCode >= 203 {
+		return &StatusError{URL: tl.config.URL, StatusCode: r.StatusCode}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		host := dnsErr.Name
+		if u, perr := url.Parse(tl.config.URL); perr == nil && u.Hostname() != "" {
+			host = u.Hostname()
+		}
+		return &LookupError{Host: host, Err: err}
+	}
+	return err
 }
 
 func (tl Taplist) Venue() string {
-	return tl.venue
+	return tl.config.Venue
 }
 
 func (tl Taplist) URL() string {
-	return tl.url
+	return tl.config.URL
 }
 
-// makeHTMLTableScraper assumes that beers are listed in an HTML table,
-// an returns a function that extract them, given HTML selectors to find
-// the beer list and the beer details within each row.
+// NewTaplist assumes that beers are listed in an HTML table, and returns a
+// Taplister that extracts them, given HTML selectors to find the beer list
+// and the beer details within each row.
+//
+// Note that colly clones share an HTTP client, so this installs a
+// context-aware transport on coll as well.
 func NewTaplist(coll *colly.Collector, c TaplistConfig) *Taplist {
 	tl := &Taplist{
+		config:    c,
 		collector: coll.Clone(),
-		venue:     c.Venue,
-		url:       c.URL,
 	}
-
-	tl.collector.OnHTML(c.TableSelector, func(table *colly.HTMLElement) {
-		table.ForEach("tr", func(_ int, row *colly.HTMLElement) {
-			beer := beerweb.Beer{
-				Brewery: row.ChildText(c.BrewerySelector),
-				Name:    row.ChildText(c.NameSelector),
-				Style:   row.ChildText(c.StyleSelector),
-				Origin:  row.ChildText(c.OriginSelector),
-				ABV:     row.ChildText(c.ABVSelector),
-			}
-			if beer.Valid() {
-				tl.Beers = append(tl.Beers, beer)
-			}
-		})
-	})
-
+	tl.collector.WithTransport(transport)
 	return tl
 }
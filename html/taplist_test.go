@@ -0,0 +1,238 @@
+package html
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly"
+	"github.com/ianfoo/beerweb"
+)
+
+const testPage = `<html><body><table class="t">
+<tr><td class="b">Fremont</td><td class="n">Lush</td><td class="a">6.5%</td></tr>
+</table></body></html>`
+
+func testTaplist(coll *colly.Collector, venue, url string) *Taplist {
+	return NewTaplist(coll, TaplistConfig{
+		Venue:           venue,
+		URL:             url,
+		TableSelector:   "table.t",
+		BrewerySelector: "td.b",
+		NameSelector:    "td.n",
+		ABVSelector:     "td.a",
+	})
+}
+
+// A venue that hangs mustn't hold up others cloned from the same collector.
+func TestHungVenueDoesNotHoldUpOthers(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(testPage))
+	}))
+	defer fast.Close()
+	hung := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hung:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(hung)
+
+	coll := colly.NewCollector()
+	venues := []beerweb.Taplister{
+		testTaplist(coll, "Fast", fast.URL),
+		testTaplist(coll, "Hung", slow.URL),
+	}
+	results := beerweb.FetchAll(context.Background(), venues, beerweb.FetchOptions{Timeout: 500 * time.Millisecond})
+	if err := results[0].Err; err != nil {
+		t.Fatalf("fast venue: %v", err)
+	}
+	if got := len(results[0].Taplist.Beers); got != 1 {
+		t.Errorf("fast venue has %d beers, want 1", got)
+	}
+	if d := results[0].Duration; d >= 500*time.Millisecond {
+		t.Errorf("fast venue took %v", d)
+	}
+	if results[1].Err == nil {
+		t.Error("hung venue didn't fail")
+	}
+}
+
+func TestFetchBeersErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		check   func(t *testing.T, url string, err error)
+	}{
+		{
+			"not found",
+			func(rw http.ResponseWriter, r *http.Request) { http.NotFound(rw, r) },
+			wantStatus(http.StatusNotFound),
+		},
+		{
+			"server error",
+			func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusBadGateway) },
+			wantStatus(http.StatusBadGateway),
+		},
+		{
+			"no content",
+			func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusNoContent) },
+			wantStatus(http.StatusNoContent),
+		},
+		{
+			"partial content",
+			func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusPartialContent)
+				rw.Write([]byte(testPage))
+			},
+			wantStatus(http.StatusPartialContent),
+		},
+		{
+			"no table",
+			func(rw http.ResponseWriter, r *http.Request) {
+				rw.Write([]byte(`<html><body><p>We've moved!</p></body></html>`))
+			},
+			func(t *testing.T, url string, err error) {
+				var noMatch *NoMatchError
+				if !errors.As(err, &noMatch) {
+					t.Fatalf("got %v, want a *NoMatchError", err)
+				}
+				if noMatch.URL != url || noMatch.Selector != "table.t" {
+					t.Errorf("got %+v", noMatch)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+			beers, err := testTaplist(colly.NewCollector(), "Pub", ts.URL).FetchBeers(context.Background())
+			if beers != nil {
+				t.Errorf("got beers %v", beers)
+			}
+			tt.check(t, ts.URL, err)
+		})
+	}
+}
+
+func wantStatus(code int) func(*testing.T, string, error) {
+	return func(t *testing.T, url string, err error) {
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("got %v, want a *StatusError", err)
+		}
+		if statusErr.StatusCode != code || statusErr.URL != url {
+			t.Errorf("got %+v, want %d from %s", statusErr, code, url)
+		}
+	}
+}
+
+func TestFetchBeersLookupError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// The .invalid top-level domain never resolves.
+	tl := testTaplist(colly.NewCollector(), "Nowhere", "http://beers.invalid/draft")
+	_, err := tl.FetchBeers(ctx)
+	if ctx.Err() != nil {
+		t.Skip("name lookup didn't fail in time")
+	}
+	var lookupErr *LookupError
+	if !errors.As(err, &lookupErr) {
+		t.Fatalf("got %v, want a *LookupError", err)
+	}
+	if lookupErr.Host != "beers.invalid" {
+		t.Errorf("host %q, want beers.invalid", lookupErr.Host)
+	}
+}
+
+// servePage serves a page listing n beers, named after the number of
+// requests made so far.
+func servePage(n int) *httptest.Server {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		req := requests
+		mu.Unlock()
+		fmt.Fprint(rw, `<html><body><table class="t">`)
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(rw, `<tr><td class="b">Brewery %d</td><td class="n">Beer %d</td><td class="a">5%%</td></tr>`, req, i)
+		}
+		fmt.Fprint(rw, `</table></body></html>`)
+	}))
+}
+
+func TestFetchBeersRepeatedly(t *testing.T) {
+	ts := servePage(3)
+	defer ts.Close()
+	tl := testTaplist(colly.NewCollector(), "Pub", ts.URL)
+	for poll := 1; poll <= 3; poll++ {
+		beers, err := tl.FetchBeers(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(beers) != 3 {
+			t.Fatalf("poll %d got %d beers, want 3: %v", poll, len(beers), beers)
+		}
+		for _, b := range beers {
+			if want := fmt.Sprintf("Brewery %d", poll); b.Brewery != want {
+				t.Errorf("poll %d got %v, want beers from %s", poll, b, want)
+			}
+		}
+	}
+}
+
+func TestFetchBeersConcurrently(t *testing.T) {
+	ts := servePage(5)
+	defer ts.Close()
+	tl := testTaplist(colly.NewCollector(), "Pub", ts.URL)
+
+	const fetches = 10
+	var (
+		wg      sync.WaitGroup
+		results = make([][]beerweb.Beer, fetches)
+		errs    = make([]error, fetches)
+	)
+	for i := 0; i < fetches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = tl.FetchBeers(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	breweries := make(map[string]bool)
+	for i, beers := range results {
+		if errs[i] != nil {
+			t.Errorf("fetch %d: %v", i, errs[i])
+			continue
+		}
+		if len(beers) != 5 {
+			t.Errorf("fetch %d got %d beers, want 5", i, len(beers))
+			continue
+		}
+		// Every beer in a fetch comes from the same response.
+		for _, b := range beers[1:] {
+			if b.Brewery != beers[0].Brewery {
+				t.Errorf("fetch %d mixes responses: %v", i, beers)
+				break
+			}
+		}
+		breweries[beers[0].Brewery] = true
+	}
+	if len(breweries) != fetches {
+		t.Errorf("got beers from %d responses, want %d", len(breweries), fetches)
+	}
+}
@@ -0,0 +1,64 @@
+package html
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// colly builds its own *http.Request values and offers no way to attach a
+// context to them, so requests are tagged with a fetch ID header in an
+// OnRequest callback, and contextTransport swaps in the matching context
+// before the request goes out on the wire.
+
+const fetchIDHeader = "X-Beerweb-Fetch-Id"
+
+var (
+	lastFetchID uint64
+	transport   = &contextTransport{
+		base: http.DefaultTransport,
+		ctxs: make(map[string]context.Context),
+	}
+)
+
+type contextTransport struct {
+	base http.RoundTripper
+
+	mu   sync.Mutex
+	ctxs map[string]context.Context
+}
+
+// register associates ctx with a new fetch ID. The returned func must be
+// called once the fetch is complete.
+func (t *contextTransport) register(ctx context.Context) (string, func()) {
+	id := strconv.FormatUint(atomic.AddUint64(&lastFetchID, 1), 10)
+	t.mu.Lock()
+	t.ctxs[id] = ctx
+	t.mu.Unlock()
+	return id, func() {
+		t.mu.Lock()
+		delete(t.ctxs, id)
+		t.mu.Unlock()
+	}
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := req.Header.Get(fetchIDHeader)
+	if id == "" {
+		return t.base.RoundTrip(req)
+	}
+	t.mu.Lock()
+	ctx, ok := t.ctxs[id]
+	t.mu.Unlock()
+	if !ok {
+		ctx = req.Context()
+	}
+	// RoundTrippers mustn't modify the request they're given, so strip the
+	// tag from a copy of the headers.
+	req = req.WithContext(ctx)
+	req.Header = req.Header.Clone()
+	req.Header.Del(fetchIDHeader)
+	return t.base.RoundTrip(req)
+}
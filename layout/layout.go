@@ -0,0 +1,143 @@
+// Package layout holds the templates that beerweb's pages and mail share,
+// so that they look alike:
+//
+//	header  the top of a page, given its title
+//	footer  the bottom of a page
+//	beers   a table of beers, given a Table
+//	style   extra styles for the page's head, empty unless it's redefined
+//
+// Pages are parsed into a template from New, which has them defined:
+//
+//	tmpl := template.Must(layout.New("Digest").Parse(`{{template "header" "Digest"}}...`))
+package layout
+
+import (
+	"html/template"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// Table is a table of beers, under a title.
+type Table struct {
+	Title string
+	Beers []beerweb.Beer
+	// Slug identifies the venue the beers are at, for the page to keep
+	// the table up to date.
+	Slug string
+	// Stale, if set, labels the table as out of date, with StaleReason
+	// shown when the label is hovered over.
+	Stale       string
+	StaleReason string
+	// Links links each beer to its history.
+	Links bool
+}
+
+// New returns an empty template with the given name, along with the shared
+// templates and the functions they use.
+func New(name string) *template.Template {
+	return template.Must(base.Clone()).New(name)
+}
+
+var base = template.Must(template.New("layout").
+	Funcs(template.FuncMap{
+		"columns": optionalColumns,
+		"keys":    beerweb.ListingKeys,
+	}).
+	Parse(headerTmplStr + footerTmplStr + beersTmplStr))
+
+// columnSet records which of the optional beer fields are present in a
+// taplist, so that empty columns can be left out of the page.
+type columnSet struct {
+	Tap, IBU, Serving, Price bool
+}
+
+// Span returns the total number of columns in the table.
+func (cs columnSet) Span() int {
+	span := 5
+	for _, present := range []bool{cs.Tap, cs.IBU, cs.Serving, cs.Price} {
+		if present {
+			span++
+		}
+	}
+	return span
+}
+
+func optionalColumns(beers []beerweb.Beer) columnSet {
+	var cs columnSet
+	for _, b := range beers {
+		cs.Tap = cs.Tap || b.Tap != ""
+		cs.IBU = cs.IBU || b.IBU != ""
+		cs.Serving = cs.Serving || b.Serving != ""
+		cs.Price = cs.Price || len(b.Prices) > 0
+	}
+	return cs
+}
+
+var semanticUICDN = `<link rel="stylesheet" type="text/css"` +
+	`href="https://cdnjs.cloudflare.com/ajax/libs/semantic-ui/2.3.3/semantic.min.css"/>`
+
+var headerTmplStr = `{{define "header"}}<!DOCTYPE html>
+<html>` + semanticUICDN + `<head>
+<title>{{ . }}</title>
+{{block "style" .}}{{end}}
+</head>
+<body>
+<div class="ui header segment">
+<div class="ui center aligned container">
+<h1>{{ . }}</h1>
+</div>
+</div>
+{{end}}`
+
+var footerTmplStr = `{{define "footer"}}
+<div class="ui footer segment">
+<div class="ui center aligned container">
+<p>Generated using <a href="https://github.com/ianfoo/beerweb">beerweb</a>.</p>
+</div>
+</div>
+</body>
+</html>{{end}}`
+
+// Each row is keyed by the beer's listing key, and each column by the
+// field it shows, for the page's script to find them by.
+var beersTmplStr = `{{define "beers"}}
+{{ $cols := columns .Beers }}
+{{ $keys := keys .Beers }}
+<table class="ui celled striped inverted compact table"{{with .Slug}} data-venue="{{ . }}"{{end}}>
+  <thead>
+  <tr>
+  <th colspan="{{ $cols.Span }}" class="ui">{{ .Title }}
+  {{with .Stale}}
+    <span class="ui yellow label" title="{{ $.StaleReason }}">{{ . }}</span>
+  {{end}}
+  </th>
+  </tr>
+  <tr class="columns">
+    {{if $cols.Tap}}<th data-col="tap">Tap</th>{{end}}
+    <th data-col="brewery">Brewery</th>
+    <th data-col="name">Name</th>
+    <th data-col="style">Style</th>
+    <th data-col="abv">ABV</th>
+    {{if $cols.IBU}}<th data-col="ibu">IBU</th>{{end}}
+    <th data-col="origin">Origin</th>
+    {{if $cols.Serving}}<th data-col="serving">Serving</th>{{end}}
+    {{if $cols.Price}}<th data-col="price">Price</th>{{end}}
+  </tr>
+  </thead>
+  <tbody>
+{{range $i, $beer := .Beers}}
+  <tr data-key="{{ index $keys $i }}">
+    {{if $cols.Tap}}<td>{{ $beer.Tap }}</td>{{end}}
+    <td>{{ $beer.Brewery }}</td>
+    <td>{{if $.Links}}<a href="/beer?brewery={{ $beer.Brewery }}&name={{ $beer.Name }}">{{ $beer.Name }}</a>{{else}}{{ $beer.Name }}{{end}}</td>
+    <td>{{ $beer.Style }}</td>
+    <td>{{ $beer.ABV }}</td>
+    {{if $cols.IBU}}<td>{{ $beer.IBU }}</td>{{end}}
+    <td>{{ $beer.Origin }}</td>
+    {{if $cols.Serving}}<td>{{ $beer.Serving }}</td>{{end}}
+    {{if $cols.Price}}<td>{{ $beer.PriceList }}</td>{{end}}
+  </tr>
+{{end}}
+  </tbody>
+</table>
+{{end}}`
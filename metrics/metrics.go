@@ -0,0 +1,327 @@
+// Package metrics keeps counters, gauges and histograms, and exposes them in
+// the Prometheus text format. It does only as much as beerweb needs.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds a set of metrics, which are written out in the order they
+// were created.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+func (r *Registry) add(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes every metric to w in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(rw)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// desc is what every metric has.
+type desc struct {
+	name, help, kind string
+	labels           []string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// series returns the name and labels of a series, like `name{a="1",b="2"}`,
+// with extra label pairs added at the end.
+func (d desc) series(suffix string, values []string, extra ...string) string {
+	var s strings.Builder
+	s.WriteString(d.name + suffix)
+	if len(d.labels)+len(extra) == 0 {
+		return s.String()
+	}
+	s.WriteByte('{')
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, l := range d.labels {
+		pairs = append(pairs, l+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	s.WriteString(strings.Join(pairs, ","))
+	s.WriteByte('}')
+	return s.String()
+}
+
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s has labels %v, but got values %q", d.name, d.labels, values))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// vec holds a value of some kind for each combination of label values.
+type vec struct {
+	desc
+	mu     sync.Mutex
+	values map[string][]string
+}
+
+func (v *vec) labelValues(key string, values []string) {
+	if _, ok := v.values[key]; !ok {
+		v.values[key] = append([]string(nil), values...)
+	}
+}
+
+// sortedKeys returns the keys of the series in a stable order.
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter is a value that only goes up, like a number of requests.
+type Counter struct {
+	vec
+	counts map[string]float64
+}
+
+// NewCounter adds a counter with the named labels to the registry.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		vec:    vec{desc: desc{name, help, "counter", labels}, values: make(map[string][]string)},
+		counts: make(map[string]float64),
+	}
+	r.add(c)
+	return c
+}
+
+// Inc adds one to the counter for the label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which mustn't be negative, to the counter for the label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter " + c.name + " can't go down")
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.labelValues(key, labelValues)
+	c.counts[key] += v
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s %s\n", c.series("", c.values[k]), formatFloat(c.counts[k]))
+	}
+}
+
+// Gauge is a value that can go up and down, like a number of beers.
+type Gauge struct {
+	vec
+	gauges map[string]float64
+}
+
+// NewGauge adds a gauge with the named labels to the registry.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{
+		vec:    vec{desc: desc{name, help, "gauge", labels}, values: make(map[string][]string)},
+		gauges: make(map[string]float64),
+	}
+	r.add(g)
+	return g
+}
+
+// Set sets the gauge for the label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.labelValues(key, labelValues)
+	g.gauges[key] = v
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w)
+	for _, k := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s %s\n", g.series("", g.values[k]), formatFloat(g.gauges[k]))
+	}
+}
+
+// Sample is the value of a series of a gauge made with NewGaugeFunc.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// gaugeFunc is a gauge whose values are worked out when they're written.
+type gaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc adds a gauge to the registry whose values come from calling
+// collect each time the metrics are written. It's for values that are
+// already kept elsewhere, or that depend on when they're asked for.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.add(&gaugeFunc{desc{name, help, "gauge", labels}, collect})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	samples := g.collect()
+	sort.SliceStable(samples, func(i, j int) bool {
+		return g.key(samples[i].LabelValues) < g.key(samples[j].LabelValues)
+	})
+	g.writeHeader(w)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s %s\n", g.series("", s.LabelValues), formatFloat(s.Value))
+	}
+}
+
+// Histogram counts observations, like durations, in buckets.
+type Histogram struct {
+	vec
+	buckets  []float64
+	observed map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// DefaultBuckets are upper bounds suitable for durations in seconds.
+var DefaultBuckets = []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// NewHistogram adds a histogram with the named labels to the registry. The
+// buckets are upper bounds, and needn't include +Inf.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{
+		vec:      vec{desc: desc{name, help, "histogram", labels}, values: make(map[string][]string)},
+		buckets:  buckets,
+		observed: make(map[string]*histogramSeries),
+	}
+	r.add(h)
+	return h
+}
+
+// Observe records a value for the label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.labelValues(key, labelValues)
+	s, ok := h.observed[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.observed[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, k := range h.sortedKeys() {
+		var (
+			s          = h.observed[k]
+			values     = h.values[k]
+			cumulative uint64
+		)
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s %d\n", h.series("_bucket", values, "le", formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s %d\n", h.series("_bucket", values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s %s\n", h.series("_sum", values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s %d\n", h.series("_count", values), s.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
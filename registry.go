@@ -0,0 +1,99 @@
+package beerweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// VenueConfig is the generic description of a venue that a FetcherFactory
+// builds a Taplister from. Options holds whatever else the venue's
+// configuration had to say, which only the factory knows how to interpret.
+type VenueConfig struct {
+	Name    string
+	URL     string
+	Options Options
+}
+
+// Options are the kind-specific settings of a venue, as decoded from JSON.
+type Options map[string]interface{}
+
+// Decode fills in v, which should be a pointer to a struct with JSON tags,
+// from the options. Options that don't correspond to a field of v are
+// reported as errors, since they're most likely typos.
+func (o Options) Decode(v interface{}) error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		// Put encoding/json's errors in terms of options.
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("bad options: %s should be a %s, not a %s",
+				typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		msg := strings.TrimPrefix(err.Error(), "json: ")
+		msg = strings.Replace(msg, "unknown field", "unknown option", 1)
+		return fmt.Errorf("bad options: %s", msg)
+	}
+	return nil
+}
+
+// FetcherFactory builds a Taplister for a venue, returning an error if the
+// venue's options don't make sense.
+type FetcherFactory func(VenueConfig) (Taplister, error)
+
+var (
+	fetchersMu sync.RWMutex
+	fetchers   = make(map[string]FetcherFactory)
+)
+
+// RegisterFetcher makes a kind of fetcher available by name, for venues
+// configured with that type. It's meant to be called from the init function
+// of the package implementing the fetcher, so that importing the package is
+// enough to make it available. It panics if the kind is registered twice,
+// or if factory is nil.
+func RegisterFetcher(kind string, factory FetcherFactory) {
+	fetchersMu.Lock()
+	defer fetchersMu.Unlock()
+	if factory == nil {
+		panic("beerweb: RegisterFetcher factory is nil")
+	}
+	if _, dup := fetchers[kind]; dup {
+		panic("beerweb: RegisterFetcher called twice for kind " + kind)
+	}
+	fetchers[kind] = factory
+}
+
+// FetcherKinds returns the names of the registered kinds of fetcher, sorted.
+func FetcherKinds() []string {
+	fetchersMu.RLock()
+	defer fetchersMu.RUnlock()
+	kinds := make([]string, 0, len(fetchers))
+	for kind := range fetchers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// NewTaplister builds a Taplister for a venue using the named kind of
+// fetcher.
+func NewTaplister(kind string, c VenueConfig) (Taplister, error) {
+	fetchersMu.RLock()
+	factory, ok := fetchers[kind]
+	fetchersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(
+			"unknown type %q (known types: %s; is the package providing it imported?)",
+			kind, strings.Join(FetcherKinds(), ", "))
+	}
+	return factory(c)
+}
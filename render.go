@@ -0,0 +1,237 @@
+package beerweb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer writes taplists out in some format.
+type Renderer interface {
+	Render(w io.Writer, taplists []Taplist) error
+	// ContentType is the MIME type of the output, for serving it.
+	ContentType() string
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"text":     TextRenderer{},
+		"json":     jsonRenderer{},
+		"ndjson":   ndjsonRenderer{},
+		"csv":      delimitedRenderer{',', "text/csv; charset=utf-8"},
+		"tsv":      delimitedRenderer{'\t', "text/tab-separated-values; charset=utf-8"},
+		"markdown": markdownRenderer{},
+		"yaml":     yamlRenderer{},
+	}
+)
+
+// RegisterRenderer makes a format available by name. It panics if the
+// format is registered twice, or if r is nil.
+func RegisterRenderer(format string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if r == nil {
+		panic("beerweb: RegisterRenderer renderer is nil")
+	}
+	if _, dup := renderers[format]; dup {
+		panic("beerweb: RegisterRenderer called twice for format " + format)
+	}
+	renderers[format] = r
+}
+
+// RenderFormats returns the names of the available formats, sorted.
+func RenderFormats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// NewRenderer returns the Renderer for the named format.
+func NewRenderer(format string) (Renderer, error) {
+	renderersMu.RLock()
+	r, ok := renderers[format]
+	renderersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (known formats: %s)",
+			format, strings.Join(RenderFormats(), ", "))
+	}
+	return r, nil
+}
+
+// TextRenderer writes a TextTable for each taplist, or says there are no
+// beers. It's the "text" format, with the default table.
+type TextRenderer struct {
+	// These are passed on to each TextTable.
+	Columns  []string
+	Border   Border
+	Color    bool
+	MaxWidth int
+	Wrap     bool
+}
+
+func (TextRenderer) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (r TextRenderer) Render(w io.Writer, taplists []Taplist) error {
+	for i, tl := range taplists {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "Beer list for "+tl.Venue)
+		if len(tl.Beers) == 0 {
+			if _, err := fmt.Fprintln(w, "No beers listed."); err != nil {
+				return err
+			}
+			continue
+		}
+		tt := NewTextTable(tl.Beers)
+		tt.Columns, tt.Border, tt.Color = r.Columns, r.Border, r.Color
+		tt.MaxWidth, tt.Wrap = r.MaxWidth, r.Wrap
+		if _, err := fmt.Fprintln(w, tt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonRenderer writes all the taplists as a single JSON array.
+type jsonRenderer struct{}
+
+func (jsonRenderer) ContentType() string { return "application/json" }
+
+func (jsonRenderer) Render(w io.Writer, taplists []Taplist) error {
+	return json.NewEncoder(w).Encode(taplists)
+}
+
+// ndjsonRenderer writes a line of JSON for each beer, including its venue.
+type ndjsonRenderer struct{}
+
+func (ndjsonRenderer) ContentType() string { return "application/x-ndjson" }
+
+func (ndjsonRenderer) Render(w io.Writer, taplists []Taplist) error {
+	enc := json.NewEncoder(w)
+	for _, tl := range taplists {
+		for _, b := range tl.Beers {
+			err := enc.Encode(struct {
+				Venue string `json:"venue"`
+				Beer
+			}{tl.Venue, b})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// delimitedRenderer writes a row for each beer, with a header row, in CSV
+// or a variation of it.
+type delimitedRenderer struct {
+	comma       rune
+	contentType string
+}
+
+func (r delimitedRenderer) ContentType() string { return r.contentType }
+
+func (r delimitedRenderer) Render(w io.Writer, taplists []Taplist) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = r.comma
+	record := []string{"venue"}
+	for _, f := range fields {
+		record = append(record, f.name)
+	}
+	cw.Write(record)
+	for _, tl := range taplists {
+		for _, b := range tl.Beers {
+			record = append(record[:0], tl.Venue)
+			for _, f := range fields {
+				record = append(record, f.value(b))
+			}
+			cw.Write(record)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownRenderer writes a GitHub-flavored Markdown table for each
+// taplist, under a heading. Columns that are empty for every beer are left
+// out.
+type markdownRenderer struct{}
+
+func (markdownRenderer) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (markdownRenderer) Render(w io.Writer, taplists []Taplist) error {
+	var s strings.Builder
+	for i, tl := range taplists {
+		if i > 0 {
+			s.WriteByte('\n')
+		}
+		s.WriteString("## " + tl.Venue + "\n\n")
+		if len(tl.Beers) == 0 {
+			s.WriteString("No beers listed.\n")
+			continue
+		}
+		tt := NewTextTable(tl.Beers)
+		tt.Border = MarkdownBorder
+		s.WriteString(tt.String() + "\n")
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// yamlRenderer writes the taplists as a YAML sequence. Empty fields are left
+// out, and ABVs are written as they're displayed, like "6.5%".
+type yamlRenderer struct{}
+
+func (yamlRenderer) ContentType() string { return "application/yaml" }
+
+func (yamlRenderer) Render(w io.Writer, taplists []Taplist) error {
+	var s strings.Builder
+	if len(taplists) == 0 {
+		s.WriteString("[]\n")
+	}
+	for _, tl := range taplists {
+		s.WriteString("- venue: " + yamlString(tl.Venue) + "\n")
+		if tl.URL != "" {
+			s.WriteString("  url: " + yamlString(tl.URL) + "\n")
+		}
+		if len(tl.Beers) == 0 {
+			s.WriteString("  beers: []\n")
+			continue
+		}
+		s.WriteString("  beers:\n")
+		for _, b := range tl.Beers {
+			prefix := "    - "
+			for _, f := range fields {
+				v := f.value(b)
+				if v == "" {
+					continue
+				}
+				s.WriteString(prefix + f.name + ": " + yamlString(v) + "\n")
+				prefix = "      "
+			}
+		}
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// yamlString quotes s as a YAML scalar. A JSON string is a valid YAML
+// double-quoted scalar, and saves working out when quotes are needed.
+func yamlString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,117 @@
+package beerweb
+
+import (
+	"strings"
+	"testing"
+)
+
+var renderTaplists = []Taplist{
+	{Venue: "Pub", URL: "http://example.com/pub", Beers: []Beer{
+		{Tap: "1", Brewery: "Fremont", Name: "Dark Star", Style: "Stout", ABV: ParseABV("8%"), Prices: []Price{{Size: "pint", Amount: "$7"}}},
+		{Tap: "12", Brewery: "Holy Mountain", Name: `The "Goat" | Kid, Too`, ABV: ParseABV("5.2")},
+	}},
+	{Venue: "Empty"},
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format   string
+		taplists []Taplist
+		want     string
+	}{
+		{"text", renderTaplists, `Beer list for Pub
+========================================================================
+| Tap | Brewery       | Name                  | Style |  ABV | Price   |
+========================================================================
+|   1 | Fremont       | Dark Star             | Stout |   8% | pint $7 |
+|  12 | Holy Mountain | The "Goat" | Kid, Too |       | 5.2% |         |
+========================================================================
+
+Beer list for Empty
+No beers listed.
+`},
+		{"text", nil, ""},
+		{"json", renderTaplists, `[{"venue":"Pub","url":"http://example.com/pub","beers":[` +
+			`{"brewery":"Fremont","name":"Dark Star","style":"Stout","abv":{"text":"8%","value":8},"origin":"","tap":"1","prices":[{"size":"pint","amount":"$7"}]},` +
+			`{"brewery":"Holy Mountain","name":"The \"Goat\" | Kid, Too","style":"","abv":{"text":"5.2","value":5.2},"origin":"","tap":"12"}]},` +
+			`{"venue":"Empty","url":"","beers":null}]
+`},
+		{"json", nil, "null\n"},
+		{"ndjson", renderTaplists, `{"venue":"Pub","brewery":"Fremont","name":"Dark Star","style":"Stout","abv":{"text":"8%","value":8},"origin":"","tap":"1","prices":[{"size":"pint","amount":"$7"}]}
+{"venue":"Pub","brewery":"Holy Mountain","name":"The \"Goat\" | Kid, Too","style":"","abv":{"text":"5.2","value":5.2},"origin":"","tap":"12"}
+`},
+		{"csv", renderTaplists, `venue,tap,brewery,name,style,abv,ibu,origin,serving,price
+Pub,1,Fremont,Dark Star,Stout,8%,,,,pint $7
+Pub,12,Holy Mountain,"The ""Goat"" | Kid, Too",,5.2%,,,,
+`},
+		{
+			// A field with a tab has to be quoted in TSV, but one with a
+			// comma doesn't.
+			"tsv",
+			[]Taplist{{Venue: "Bar, Too", Beers: []Beer{{Brewery: "Schilling", Name: "Excelsior\tCider", Serving: "can"}}}},
+			"venue\ttap\tbrewery\tname\tstyle\tabv\tibu\torigin\tserving\tprice\n" +
+				"Bar, Too\t\tSchilling\t\"Excelsior\tCider\"\t\t\t\t\tcan\t\n",
+		},
+		{"csv", nil, "venue,tap,brewery,name,style,abv,ibu,origin,serving,price\n"},
+		{"markdown", renderTaplists, `## Pub
+
+| Tap | Brewery       | Name                   | Style |  ABV | Price   |
+|----:|---------------|------------------------|-------|-----:|---------|
+|   1 | Fremont       | Dark Star              | Stout |   8% | pint $7 |
+|  12 | Holy Mountain | The "Goat" \| Kid, Too |       | 5.2% |         |
+
+## Empty
+
+No beers listed.
+`},
+		{"yaml", renderTaplists, `- venue: "Pub"
+  url: "http://example.com/pub"
+  beers:
+    - tap: "1"
+      brewery: "Fremont"
+      name: "Dark Star"
+      style: "Stout"
+      abv: "8%"
+      price: "pint $7"
+    - tap: "12"
+      brewery: "Holy Mountain"
+      name: "The \"Goat\" | Kid, Too"
+      abv: "5.2%"
+- venue: "Empty"
+  beers: []
+`},
+		{"yaml", nil, "[]\n"},
+	}
+	for _, tt := range tests {
+		r, err := NewRenderer(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s strings.Builder
+		if err := r.Render(&s, tt.taplists); err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if got := s.String(); got != tt.want {
+			t.Errorf("%s of %d taplists got\n%s\nwant\n%s", tt.format, len(tt.taplists), got, tt.want)
+		}
+	}
+}
+
+func TestNewRenderer(t *testing.T) {
+	want := []string{"csv", "json", "markdown", "ndjson", "text", "tsv", "yaml"}
+	for _, format := range want {
+		r, err := NewRenderer(format)
+		if err != nil {
+			t.Errorf("NewRenderer(%q): %v", format, err)
+			continue
+		}
+		if r.ContentType() == "" {
+			t.Errorf("%s has no content type", format)
+		}
+	}
+	_, err := NewRenderer("xml")
+	if err == nil || !strings.Contains(err.Error(), "csv, json, markdown, ndjson, text, tsv, yaml") {
+		t.Errorf("unknown format got %v, want an error listing the formats", err)
+	}
+}
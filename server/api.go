@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// The JSON API serves the same taplists as the web page, along with how
+// fresh they are.
+//
+//	GET /api/v1/taplists       all venues and their beers
+//	GET /api/v1/venues/{slug}  a single venue and its beers
+//	GET /api/v1/beers          every beer, each with its venue
+
+const apiPrefix = "/api/v1/"
+
+type apiVenue struct {
+	Slug        string         `json:"slug"`
+	Venue       string         `json:"venue"`
+	URL         string         `json:"url"`
+	LastSuccess *time.Time     `json:"last_success,omitempty"`
+	LastAttempt *time.Time     `json:"last_attempt,omitempty"`
+	Stale       bool           `json:"stale"`
+	Error       string         `json:"error,omitempty"`
+	Beers       []beerweb.Beer `json:"beers"`
+}
+
+type apiBeer struct {
+	Venue     string `json:"venue"`
+	VenueSlug string `json:"venue_slug"`
+	Stale     bool   `json:"stale"`
+	beerweb.Beer
+}
+
+func newAPIVenue(s *beerweb.VenueState) apiVenue {
+	v := apiVenue{
+		Slug:  s.Taplist.Slug(),
+		Venue: s.Taplist.Venue,
+		URL:   s.Taplist.URL,
+		Stale: s.Stale(),
+		Beers: s.Taplist.Beers,
+	}
+	if v.Beers == nil {
+		v.Beers = []beerweb.Beer{}
+	}
+	if !s.LastSuccess.IsZero() {
+		t := s.LastSuccess
+		v.LastSuccess = &t
+	}
+	if !s.LastAttempt.IsZero() {
+		t := s.LastAttempt
+		v.LastAttempt = &t
+	}
+	if s.LastError != nil {
+		v.Error = s.LastError.Error()
+	}
+	return v
+}
+
+func (s *Server) apiHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		writeAPIError(rw, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	switch {
+	case path == "taplists":
+		venues := make([]apiVenue, len(s.states))
+		for i, vs := range s.states {
+			venues[i] = newAPIVenue(vs)
+		}
+		writeJSON(rw, http.StatusOK, struct {
+			Taplists []apiVenue `json:"taplists"`
+		}{venues})
+
+	case strings.HasPrefix(path, "venues/"):
+		slug := strings.TrimPrefix(path, "venues/")
+		for _, vs := range s.states {
+			if vs.Taplist.Slug() == slug {
+				writeJSON(rw, http.StatusOK, newAPIVenue(vs))
+				return
+			}
+		}
+		writeAPIError(rw, http.StatusNotFound, "no venue "+slug)
+
+	case path == "beers":
+		beers := []apiBeer{}
+		for _, vs := range s.states {
+			for _, b := range vs.Taplist.Beers {
+				beers = append(beers, apiBeer{
+					Venue:     vs.Taplist.Venue,
+					VenueSlug: vs.Taplist.Slug(),
+					Stale:     vs.Stale(),
+					Beer:      b,
+				})
+			}
+		}
+		writeJSON(rw, http.StatusOK, struct {
+			Beers []apiBeer `json:"beers"`
+		}{beers})
+
+	default:
+		writeAPIError(rw, http.StatusNotFound, "not found")
+	}
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Println("error writing JSON response:", err)
+	}
+}
+
+func writeAPIError(rw http.ResponseWriter, status int, msg string) {
+	writeJSON(rw, status, struct {
+		Error string `json:"error"`
+	}{msg})
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// The taplist page listens to /events, a stream of server-sent events, so
+// it can show changes without being reloaded. Each change to a venue's
+// taplist is sent as a "taplist" event whose data is a taplistEvent.
+
+// taplistEvent describes a change to a venue's taplist in terms of table
+// rows, so the page doesn't need to know how to format a beer.
+type taplistEvent struct {
+	Venue   string     `json:"venue"`
+	Slug    string     `json:"slug"`
+	Added   []eventRow `json:"added"`
+	Removed []string   `json:"removed"`
+	Changed []eventRow `json:"changed"`
+}
+
+// eventRow is a row of the taplist table. Key identifies the row, as one of
+// beerweb.ListingKeys, and Cells has the text of each column, keyed by the
+// column's data-col attribute.
+type eventRow struct {
+	Key   string            `json:"key"`
+	Link  string            `json:"link"`
+	Cells map[string]string `json:"cells"`
+}
+
+func newEventRow(key string, b beerweb.Beer) eventRow {
+	return eventRow{
+		Key:  key,
+		Link: "/beer?" + url.Values{"brewery": {b.Brewery}, "name": {b.Name}}.Encode(),
+		Cells: map[string]string{
+			"tap":     b.Tap,
+			"brewery": b.Brewery,
+			"name":    b.Name,
+			"style":   b.Style,
+			"abv":     b.ABV.String(),
+			"ibu":     b.IBU,
+			"origin":  b.Origin,
+			"serving": b.Serving,
+			"price":   b.PriceList(),
+		},
+	}
+}
+
+// newTaplistEvent describes the change from one version of a taplist to the
+// next, as beerweb.Diff sees it. Rows are keyed the way the page's are, so
+// that the right one of a beer's two listings is removed.
+func newTaplistEvent(prev, next beerweb.Taplist) taplistEvent {
+	d := beerweb.Diff(prev, next)
+	e := taplistEvent{
+		Venue:   d.Venue,
+		Slug:    beerweb.Slug(d.Venue),
+		Added:   []eventRow{},
+		Removed: []string{},
+		Changed: []eventRow{},
+	}
+	added, removed := d.Keys(prev.Beers)
+	for i, b := range d.Added {
+		e.Added = append(e.Added, newEventRow(added[i], b))
+	}
+	e.Removed = append(e.Removed, removed...)
+	for _, c := range d.Changed {
+		e.Changed = append(e.Changed, newEventRow(c.Key, c.New))
+	}
+	return e
+}
+
+// broker hands events out to everyone listening to the stream.
+type broker struct {
+	mu     sync.Mutex
+	subs   map[chan []byte]bool
+	closed bool
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[chan []byte]bool)}
+}
+
+// subscribe returns a channel of events, which is closed when the broker is
+// or if the subscriber falls too far behind, and a function to unsubscribe.
+// The channel is nil if the broker is already closed.
+func (b *broker) subscribe() (<-chan []byte, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, func() {}
+	}
+	ch := make(chan []byte, 16)
+	b.subs[ch] = true
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.subs[ch] {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends an event to every subscriber. A subscriber that isn't
+// keeping up is dropped rather than holding up the others; its page will
+// reconnect and reload.
+func (b *broker) publish(event []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// close ends every subscription, and refuses new ones.
+func (b *broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// publishChange sends a change to a venue's taplist to the pages listening.
+func (s *Server) publishChange(prev, next beerweb.Taplist) {
+	data, err := json.Marshal(newTaplistEvent(prev, next))
+	if err != nil {
+		log.Println("error encoding taplist event:", err)
+		return
+	}
+	s.events.publish([]byte(fmt.Sprintf("event: taplist\ndata: %s\n\n", data)))
+}
+
+// keepAlive is how often a comment is sent on an idle event stream, so
+// that proxies don't give up on it.
+const keepAlive = 30 * time.Second
+
+func (s *Server) eventsHandler(rw http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(rw)
+	// The server's write timeout is meant for pages, not for streams
+	// that stay open for hours.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		log.Println("error clearing write deadline for events:", err)
+	}
+
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+	if events == nil {
+		http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	// Tell the browser how soon to reconnect if the stream is lost.
+	fmt.Fprint(rw, "retry: 10000\n\n")
+	if err := rc.Flush(); err != nil {
+		log.Println("can't stream events:", err)
+		return
+	}
+
+	t := time.NewTicker(keepAlive)
+	defer t.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			rw.Write(event)
+		case <-t.C:
+			fmt.Fprint(rw, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ianfoo/beerweb"
+)
+
+func TestTaplistEventDuplicateListings(t *testing.T) {
+	draft := beerweb.Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "draft"}
+	nitro := beerweb.Beer{Brewery: "Fremont", Name: "Dark Star", Serving: "nitro"}
+	lush := beerweb.Beer{Brewery: "Fremont", Name: "Lush"}
+	prev := beerweb.Taplist{Venue: "Pub", Beers: []beerweb.Beer{draft, lush, nitro}}
+
+	tests := []struct {
+		name    string
+		beers   []beerweb.Beer
+		added   []string
+		removed []string
+		changed []string
+	}{
+		{"second listing removed", []beerweb.Beer{draft, lush}, nil, []string{"fremont|dark star#2"}, nil},
+		{"first listing removed", []beerweb.Beer{lush, nitro}, nil, []string{"fremont|dark star#2"}, []string{"fremont|dark star"}},
+		{"third listing added", []beerweb.Beer{draft, lush, nitro, draft}, []string{"fremont|dark star#3"}, nil, nil},
+		{"both listings removed", []beerweb.Beer{lush}, nil, []string{"fremont|dark star", "fremont|dark star#2"}, nil},
+		{"listing added before the others", []beerweb.Beer{nitro, draft, lush, draft}, []string{"fremont|dark star#3"}, nil, []string{"fremont|dark star", "fremont|dark star#2"}},
+		{"unchanged", prev.Beers, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTaplistEvent(prev, beerweb.Taplist{Venue: "Pub", Beers: tt.beers})
+			if got := rowKeys(e.Added); !equalKeys(got, tt.added) {
+				t.Errorf("added %v, want %v", got, tt.added)
+			}
+			if !equalKeys(e.Removed, tt.removed) {
+				t.Errorf("removed %v, want %v", e.Removed, tt.removed)
+			}
+			if got := rowKeys(e.Changed); !equalKeys(got, tt.changed) {
+				t.Errorf("changed %v, want %v", got, tt.changed)
+			}
+
+			// The keys have to be the ones the page's rows have.
+			prevKeys := keySet(beerweb.ListingKeys(prev.Beers))
+			nextKeys := keySet(beerweb.ListingKeys(tt.beers))
+			for _, key := range e.Removed {
+				if !prevKeys[key] || nextKeys[key] {
+					t.Errorf("removed %q isn't a row that's gone", key)
+				}
+			}
+			for _, key := range append(rowKeys(e.Added), rowKeys(e.Changed)...) {
+				if !nextKeys[key] {
+					t.Errorf("%q isn't a row of the new taplist", key)
+				}
+			}
+		})
+	}
+}
+
+func rowKeys(rows []eventRow) []string {
+	var keys []string
+	for _, r := range rows {
+		keys = append(keys, r.Key)
+	}
+	return keys
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+func equalKeys(a, b []string) bool {
+	return len(a) == 0 && len(b) == 0 || reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// The taplist page can be filtered with query parameters, so that links
+// like /?style=ipa&max_abv=6 can be shared.
+//
+//	q        words to find in the name or brewery of a beer
+//	venue    venue slug
+//	brewery  part of the brewery name
+//	style    part of the style
+//	origin   part of the origin
+//	min_abv  lowest ABV, in percent
+//	max_abv  highest ABV, in percent
+
+func parseFilter(q url.Values) (beerweb.Filter, error) {
+	f := beerweb.Filter{
+		Query:   strings.TrimSpace(q.Get("q")),
+		Venue:   strings.TrimSpace(q.Get("venue")),
+		Brewery: strings.TrimSpace(q.Get("brewery")),
+		Style:   strings.TrimSpace(q.Get("style")),
+		Origin:  strings.TrimSpace(q.Get("origin")),
+	}
+	for _, bound := range []struct {
+		param string
+		value *float64
+	}{
+		{"min_abv", &f.MinABV},
+		{"max_abv", &f.MaxABV},
+	} {
+		s := strings.TrimSpace(strings.TrimSuffix(q.Get(bound.param), "%"))
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		// ParseFloat takes "NaN" and "Inf" too, which match nothing or
+		// everything.
+		if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return f, fmt.Errorf("%s should be a percentage, like 6.5", bound.param)
+		}
+		*bound.value = v
+	}
+	return f, nil
+}
+
+// page is what the taplist template is rendered from.
+type page struct {
+	// Query holds the filter parameters, for filling in the form.
+	Query url.Values
+	// Venues lists all venues, for the venue selector.
+	Venues []beerweb.Taplist
+	// States are the venues that are selected by the filter, with only
+	// the beers that match it.
+	States   []*beerweb.VenueState
+	Filtered bool
+	Error    string
+}
+
+func newPage(q url.Values, states []*beerweb.VenueState) page {
+	p := page{Query: q}
+	for _, s := range states {
+		p.Venues = append(p.Venues, s.Taplist)
+	}
+
+	f, err := parseFilter(q)
+	if err != nil {
+		p.Error = err.Error()
+		p.States = states
+		return p
+	}
+	if f.Empty() {
+		p.States = states
+		return p
+	}
+
+	p.Filtered = true
+	for _, s := range states {
+		if !f.MatchVenue(s.Taplist.Venue) {
+			continue
+		}
+		fs := *s
+		fs.Taplist = f.Apply(s.Taplist)
+		if len(fs.Taplist.Beers) == 0 && s.Fetched() {
+			continue
+		}
+		p.States = append(p.States, &fs)
+	}
+	return p
+}
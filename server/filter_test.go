@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    beerweb.Filter
+		wantErr string
+	}{
+		{"", beerweb.Filter{}, ""},
+		{"q=+pliny+elder+&venue=pub", beerweb.Filter{Query: "pliny elder", Venue: "pub"}, ""},
+		{"brewery=fremont&style=ipa&origin=wa", beerweb.Filter{Brewery: "fremont", Style: "ipa", Origin: "wa"}, ""},
+		{"min_abv=6.5&max_abv=8", beerweb.Filter{MinABV: 6.5, MaxABV: 8}, ""},
+		{"min_abv=6%25&max_abv=+", beerweb.Filter{MinABV: 6}, ""},
+		{"min_abv=strong", beerweb.Filter{}, "min_abv should be a percentage"},
+		{"max_abv=-1", beerweb.Filter{}, "max_abv should be a percentage"},
+		{"max_abv=NaN", beerweb.Filter{}, "max_abv should be a percentage"},
+		{"min_abv=inf", beerweb.Filter{}, "min_abv should be a percentage"},
+	}
+	for _, tt := range tests {
+		q, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parseFilter(q)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q got error %v, want %q", tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if f != tt.want {
+			t.Errorf("%q got %+v, want %+v", tt.query, f, tt.want)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	fetched := func(venue string, beers ...beerweb.Beer) *beerweb.VenueState {
+		return &beerweb.VenueState{
+			Taplist:     beerweb.Taplist{Venue: venue, Beers: beers},
+			LastSuccess: time.Now(),
+		}
+	}
+	states := []*beerweb.VenueState{
+		fetched("Local Pub", lush, pliny),
+		fetched("Other Pub", darkStar),
+		{Taplist: beerweb.Taplist{Venue: "New Pub"}},
+	}
+	tests := []struct {
+		query    string
+		want     []string // venue: number of beers
+		filtered bool
+		err      string
+	}{
+		{"", []string{"Local Pub: 2", "Other Pub: 1", "New Pub: 0"}, false, ""},
+		// Venues with no matching beers are left out, unless they
+		// haven't been fetched yet.
+		{"brewery=fremont", []string{"Local Pub: 1", "Other Pub: 1", "New Pub: 0"}, true, ""},
+		{"q=pliny", []string{"Local Pub: 1", "New Pub: 0"}, true, ""},
+		{"venue=other-pub", []string{"Other Pub: 1"}, true, ""},
+		{"min_abv=lots", []string{"Local Pub: 2", "Other Pub: 1", "New Pub: 0"}, false, "min_abv should be a percentage, like 6.5"},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		p := newPage(q, states)
+		var got []string
+		for _, s := range p.States {
+			got = append(got, s.Taplist.Venue+": "+strconv.Itoa(len(s.Taplist.Beers)))
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%q got %q, want %q", tt.query, got, tt.want)
+		}
+		if p.Filtered != tt.filtered || p.Error != tt.err {
+			t.Errorf("%q got filtered %v and error %q", tt.query, p.Filtered, p.Error)
+		}
+		if len(p.Venues) != len(states) {
+			t.Errorf("%q has %d venues to choose from, want %d", tt.query, len(p.Venues), len(states))
+		}
+	}
+	// Filtering doesn't change the states themselves.
+	if len(states[0].Taplist.Beers) != 2 {
+		t.Errorf("state was changed: %+v", states[0].Taplist)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// healthHandler reports that the process is up and serving requests.
+func healthHandler(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, http.StatusOK, struct {
+		Status string `json:"status"`
+	}{"ok"})
+}
+
+type readyVenue struct {
+	Slug        string     `json:"slug"`
+	Venue       string     `json:"venue"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Beers       int        `json:"beers"`
+	Fresh       bool       `json:"fresh"`
+}
+
+type readiness struct {
+	Ready  bool         `json:"ready"`
+	Reason string       `json:"reason,omitempty"`
+	Venues []readyVenue `json:"venues"`
+}
+
+// checkReady works out whether the server is ready for traffic as of now:
+// some venue has been fetched since the server started, and if ReadyPolls
+// is more than 0, every venue has been fetched successfully within that many
+// polls. The caller must hold s.mu.
+func (s *Server) checkReady(now time.Time) readiness {
+	var (
+		freshPolls = s.config.ReadyPolls
+		freshness  = time.Duration(freshPolls) * s.config.PollInterval
+		rd         = readiness{Venues: make([]readyVenue, len(s.states))}
+		fetched    bool
+		stale      []string
+	)
+	for i, vs := range s.states {
+		// It's the same as the API has to say, minus the beers.
+		v := newAPIVenue(vs)
+		rv := readyVenue{
+			Slug:        v.Slug,
+			Venue:       v.Venue,
+			LastAttempt: v.LastAttempt,
+			LastSuccess: v.LastSuccess,
+			Error:       v.Error,
+			Beers:       len(v.Beers),
+			Fresh:       vs.Fetched() && now.Sub(vs.LastSuccess) <= freshness,
+		}
+		if freshPolls <= 0 {
+			rv.Fresh = vs.Fetched() && !vs.Stale()
+		}
+		rd.Venues[i] = rv
+		if vs.Fetched() && !vs.LastSuccess.Before(s.started) {
+			fetched = true
+		}
+		if !rv.Fresh {
+			stale = append(stale, rv.Slug)
+		}
+	}
+
+	switch {
+	case !fetched:
+		rd.Reason = "no venue has been fetched yet"
+	case freshPolls > 0 && len(stale) > 0:
+		rd.Reason = fmt.Sprintf("venues not fetched within %d polls: %s",
+			freshPolls, strings.Join(stale, ", "))
+	default:
+		rd.Ready = true
+	}
+	return rd
+}
+
+// readyHandler reports whether there are taplists worth serving, with 503
+// Service Unavailable if not, along with the state of each venue.
+func (s *Server) readyHandler(rw http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	rd := s.checkReady(time.Now())
+	s.mu.RUnlock()
+
+	status := http.StatusOK
+	if !rd.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(rw, status, rd)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// beerPage is what the beer history template is rendered from.
+type beerPage struct {
+	History beerweb.BeerHistory
+	Now     time.Time
+}
+
+// beerHistoryHandler shows when and where a beer has been on tap, given its
+// brewery and name as query parameters.
+func (s *Server) beerHistoryHandler(rw http.ResponseWriter, r *http.Request) {
+	t := time.Now()
+	defer func() {
+		log.Printf("serviced request from %s in %v", r.RemoteAddr, time.Since(t))
+	}()
+
+	q := r.URL.Query()
+	brewery, name := q.Get("brewery"), q.Get("name")
+	if brewery == "" || name == "" {
+		http.Error(rw, "brewery and name are required", http.StatusBadRequest)
+		return
+	}
+	logged, err := s.changes.Changes()
+	if err != nil {
+		log.Println("error reading change log:", err)
+		http.Error(rw, "can't read beer history", http.StatusInternalServerError)
+		return
+	}
+	s.tmpl.ExecuteTemplate(rw, "Beer", beerPage{
+		History: beerweb.FindBeer(logged, brewery, name),
+		Now:     t,
+	})
+}
+
+var beerTmplStr = `{{define "Beer"}}{{template "header" "Beer History"}}
+{{ $h := .History }}
+<div class="ui one column container">
+<div class="column">
+<h2 class="ui header">{{ $h.Name }}<div class="sub header">{{ $h.Brewery }}</div></h2>
+{{if not $h.Stints}}
+<div class="ui message">
+  <div class="header">This beer hasn't been on tap anywhere we know of</div>
+</div>
+{{else}}
+<div class="ui statistics">
+  <div class="statistic">
+    <div class="label">First seen</div>
+    <div class="text value">{{ $h.FirstSeen.Format "Jan 2, 2006" }}</div>
+  </div>
+  <div class="statistic">
+    <div class="label">Last seen</div>
+    <div class="text value">{{if $h.OnTap}}On tap now{{else}}{{ ($h.LastSeen $.Now).Format "Jan 2, 2006" }}{{end}}</div>
+  </div>
+</div>
+<table class="ui celled striped inverted compact table">
+  <thead>
+  <tr>
+    <th>Venue</th>
+    <th>On</th>
+    <th>Off</th>
+    <th>Lasted</th>
+  </tr>
+  </thead>
+  <tbody>
+{{range $stint := $h.Stints}}
+  <tr>
+    <td>{{ $stint.Venue }}</td>
+    <td>{{ $stint.Start.Format "Jan 2, 2006 3:04 PM" }}</td>
+    <td>{{if $stint.OnTap}}Still on tap{{else}}{{ $stint.End.Format "Jan 2, 2006 3:04 PM" }}{{end}}</td>
+    <td>{{ duration ($stint.Duration $.Now) }}</td>
+  </tr>
+{{end}}
+</tbody>
+</table>
+{{end}}
+<p><a href="/">Back to the beer lists</a></p>
+</div>
+</div>
+{{template "footer"}}{{end}}`
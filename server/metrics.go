@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/html"
+	"github.com/ianfoo/beerweb/metrics"
+)
+
+// serverMetrics are the metrics a Server keeps about itself.
+type serverMetrics struct {
+	registry      *metrics.Registry
+	fetchDuration *metrics.Histogram
+	fetchesTotal  *metrics.Counter
+	httpRequests  *metrics.Counter
+}
+
+func newServerMetrics(s *Server) *serverMetrics {
+	registry := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry: registry,
+		fetchDuration: registry.NewHistogram(
+			"beerweb_fetch_duration_seconds",
+			"How long fetching a venue's taplist took, including retries.",
+			metrics.DefaultBuckets, "venue"),
+		fetchesTotal: registry.NewCounter(
+			"beerweb_fetches_total",
+			`Taplist fetches by venue and result, which is "ok" or the kind of error.`,
+			"venue", "result"),
+		httpRequests: registry.NewCounter(
+			"beerweb_http_requests_total",
+			"HTTP requests served, by handler and status code.",
+			"handler", "code"),
+	}
+	registry.NewGaugeFunc(
+		"beerweb_beers",
+		"Number of beers on each venue's last good taplist.",
+		[]string{"venue"},
+		func() []metrics.Sample {
+			return s.venueSamples(func(vs *beerweb.VenueState) float64 {
+				return float64(len(vs.Taplist.Beers))
+			})
+		})
+	registry.NewGaugeFunc(
+		"beerweb_last_success_timestamp_seconds",
+		"When each venue was last fetched successfully, in seconds since the epoch, or 0 if it never has been.",
+		[]string{"venue"},
+		func() []metrics.Sample {
+			return s.venueSamples(func(vs *beerweb.VenueState) float64 {
+				if !vs.Fetched() {
+					return 0
+				}
+				return float64(vs.LastSuccess.UnixNano()) / 1e9
+			})
+		})
+	registry.NewGaugeFunc(
+		"beerweb_poll_lag_seconds",
+		"How far behind schedule the poll loop is; more than 0 means a poll is overdue.",
+		nil,
+		func() []metrics.Sample {
+			return []metrics.Sample{{Value: s.pollLag(time.Now()).Seconds()}}
+		})
+	return m
+}
+
+func (s *Server) venueSamples(value func(*beerweb.VenueState) float64) []metrics.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	samples := make([]metrics.Sample, len(s.states))
+	for i, vs := range s.states {
+		samples[i] = metrics.Sample{
+			LabelValues: []string{vs.Taplist.Slug()},
+			Value:       value(vs),
+		}
+	}
+	return samples
+}
+
+// recordFetch updates the fetch metrics for a venue after its state has been
+// updated with the result.
+func (m *serverMetrics) recordFetch(state *beerweb.VenueState, r beerweb.Result) {
+	venue := state.Taplist.Slug()
+	m.fetchDuration.Observe(r.Duration.Seconds(), venue)
+	m.fetchesTotal.Inc(venue, errorClass(state.LastError))
+}
+
+// errorClass sorts fetch errors into a few kinds that are worth alerting on
+// differently.
+func errorClass(err error) string {
+	var (
+		statusErr  *html.StatusError
+		lookupErr  *html.LookupError
+		noMatchErr *html.NoMatchError
+		netErr     net.Error
+	)
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, beerweb.ErrNoBeers):
+		return "no_beers"
+	case errors.As(err, &noMatchErr):
+		return "no_match"
+	case errors.As(err, &statusErr):
+		return "http_status"
+	case errors.As(err, &lookupErr):
+		return "dns"
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	return "other"
+}
+
+// instrument counts the requests served by a handler.
+func (s *Server) instrument(name string, h http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		sr := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		h(sr, r)
+		s.metrics.httpRequests.Inc(name, strconv.Itoa(sr.status))
+	}
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if !sr.wroteHeader {
+		sr.status = code
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController get at the underlying writer, to
+// flush event streams.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+func (sr *statusRecorder) Write(p []byte) (int, error) {
+	sr.wroteHeader = true
+	return sr.ResponseWriter.Write(p)
+}
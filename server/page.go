@@ -0,0 +1,214 @@
+package server
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/layout"
+)
+
+var tmpl = template.Must(layout.New("Taplists").
+	Funcs(template.FuncMap{
+		"table":    venueTable,
+		"duration": beerweb.HumanDuration,
+	}).
+	Parse(tmplStr + beerTmplStr))
+
+// venueTable describes the table of a venue's beers on the page.
+func venueTable(s *beerweb.VenueState) layout.Table {
+	t := layout.Table{
+		Title: "Beers at " + s.Taplist.Venue,
+		Beers: s.Taplist.Beers,
+		Slug:  s.Taplist.Slug(),
+		Links: true,
+	}
+	if s.Stale() {
+		t.Stale = "stale since " + s.LastSuccess.Format("Jan 2 3:04 PM")
+		t.StaleReason = s.LastError.Error()
+	}
+	return t
+}
+
+func (s *Server) beerHandler(rw http.ResponseWriter, r *http.Request) {
+	t := time.Now()
+	defer func() {
+		log.Printf("serviced request from %s in %v", r.RemoteAddr, time.Since(t))
+	}()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.tmpl.ExecuteTemplate(rw, "Taplists", newPage(r.URL.Query(), s.states))
+}
+
+var tmplStr = `{{template "header" "Beer Lists"}}
+<div class="ui container">
+<form class="ui form{{if .Error}} error{{end}}" method="get" action="/">
+  <div class="fields">
+    <div class="five wide field">
+      <input type="text" name="q" placeholder="Search beers and breweries" value="{{ .Query.Get "q" }}">
+    </div>
+    <div class="four wide field">
+      <select name="venue" class="ui dropdown">
+        <option value="">All venues</option>
+        {{range $venue := .Venues}}
+        <option value="{{ $venue.Slug }}"{{if eq ($.Query.Get "venue") $venue.Slug}} selected{{end}}>{{ $venue.Venue }}</option>
+        {{end}}
+      </select>
+    </div>
+    <div class="three wide field">
+      <input type="text" name="brewery" placeholder="Brewery" value="{{ .Query.Get "brewery" }}">
+    </div>
+    <div class="two wide field">
+      <input type="text" name="style" placeholder="Style" value="{{ .Query.Get "style" }}">
+    </div>
+    <div class="two wide field">
+      <input type="text" name="origin" placeholder="Origin" value="{{ .Query.Get "origin" }}">
+    </div>
+  </div>
+  <div class="fields">
+    <div class="three wide field">
+      <input type="text" name="min_abv" placeholder="Min ABV %" value="{{ .Query.Get "min_abv" }}">
+    </div>
+    <div class="three wide field">
+      <input type="text" name="max_abv" placeholder="Max ABV %" value="{{ .Query.Get "max_abv" }}">
+    </div>
+    <div class="field">
+      <button class="ui primary button" type="submit">Filter</button>
+      {{if or .Filtered .Error}}<a class="ui button" href="/">Clear</a>{{end}}
+    </div>
+  </div>
+  {{if .Error}}<div class="ui error message"><p>{{ .Error }}</p></div>{{end}}
+</form>
+</div>
+<div class="ui hidden divider"></div>
+{{if and .Filtered (not .States)}}
+<div class="ui container">
+<div class="ui message"><div class="header">No beers match</div></div>
+</div>
+<div class="ui hidden divider"></div>
+{{end}}
+{{range $state := .States}}
+{{ $taplist := $state.Taplist }}
+<div class="ui one column container">
+<div class="column">
+{{if not $state.Fetched}}
+{{if $state.LastError}}
+<div class="ui negative message">
+  <div class="header">Beers at {{ $taplist.Venue }} are unavailable</div>
+  <p>{{ $state.LastError }}</p>
+</div>
+{{else}}
+<div class="ui message">
+  <div class="header">Beers at {{ $taplist.Venue }} haven't been fetched yet</div>
+</div>
+{{end}}
+{{else}}
+{{template "beers" (table $state)}}
+{{end}}
+</div>
+</div>
+<div class="ui hidden divider"></div>
+{{end}}
+<script>` + eventsScript + `</script>
+{{template "footer"}}`
+
+// eventsScript keeps the taplist page up to date from the event stream.
+// Anything it can't patch in place, like a venue that wasn't shown before,
+// a filtered page, or a stream that was lost and may have missed changes,
+// reloads the page instead.
+var eventsScript = `
+(function() {
+  if (!window.EventSource) {
+    return;
+  }
+  var filtered = window.location.search.length > 1;
+  var connected = false;
+  var events = new EventSource("/events");
+  events.onopen = function() {
+    if (connected) {
+      window.location.reload();
+    }
+    connected = true;
+  };
+  function cellsFor(table, beer) {
+    var cols = table.querySelectorAll("tr.columns th"), cells = [];
+    var shown = {};
+    for (var i = 0; i < cols.length; i++) {
+      var col = cols[i].getAttribute("data-col"), td = document.createElement("td");
+      shown[col] = true;
+      if (col === "name") {
+        var a = document.createElement("a");
+        a.href = beer.link;
+        a.textContent = beer.cells.name;
+        td.appendChild(a);
+      } else {
+        td.textContent = beer.cells[col] || "";
+      }
+      cells.push(td);
+    }
+    for (var c in beer.cells) {
+      if (beer.cells[c] && !shown[c]) {
+        return null;
+      }
+    }
+    return cells;
+  }
+  function findRow(table, key) {
+    var rows = table.querySelectorAll("tbody tr");
+    for (var i = 0; i < rows.length; i++) {
+      if (rows[i].getAttribute("data-key") === key) {
+        return rows[i];
+      }
+    }
+    return null;
+  }
+  function fill(row, cells) {
+    while (row.firstChild) {
+      row.removeChild(row.firstChild);
+    }
+    cells.forEach(function(td) { row.appendChild(td); });
+  }
+  events.addEventListener("taplist", function(e) {
+    var change = JSON.parse(e.data);
+    var table = document.querySelector('table[data-venue="' + change.slug + '"]');
+    if (filtered || !table) {
+      window.location.reload();
+      return;
+    }
+    var tbody = table.querySelector("tbody"), ok = true;
+    change.removed.forEach(function(key) {
+      var row = findRow(table, key);
+      if (row) {
+        tbody.removeChild(row);
+      }
+    });
+    change.changed.forEach(function(beer) {
+      var row = findRow(table, beer.key), cells = cellsFor(table, beer);
+      if (!row || !cells) {
+        ok = false;
+        return;
+      }
+      fill(row, cells);
+      row.className = "warning";
+    });
+    change.added.forEach(function(beer) {
+      var cells = cellsFor(table, beer);
+      if (!cells) {
+        ok = false;
+        return;
+      }
+      var row = document.createElement("tr");
+      row.setAttribute("data-key", beer.key);
+      fill(row, cells);
+      row.className = "positive";
+      row.title = "New since the page was loaded";
+      tbody.appendChild(row);
+    });
+    if (!ok) {
+      window.location.reload();
+    }
+  });
+})();
+`
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/digest"
+	"github.com/ianfoo/beerweb/webhook"
+)
+
+// Run polls the venues right away and then every PollInterval, until ctx is
+// done.
+func (s *Server) Run(ctx context.Context) {
+	s.Poll(ctx)
+
+	t := time.NewTicker(s.config.PollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.Poll(ctx)
+		case <-ctx.Done():
+			log.Println("exiting beer fetch goroutine")
+			return
+		}
+	}
+}
+
+// Poll fetches every venue once, and records the results.
+func (s *Server) Poll(ctx context.Context) {
+	var (
+		t          = time.Now()
+		totalBeers int
+		fetched    int
+	)
+	defer func() {
+		log.Printf(
+			"fetched %d beers from %d venues in %v",
+			totalBeers, fetched, time.Since(t))
+	}()
+
+	log.Println("fetching beers")
+	s.pollStarted(t)
+	results := beerweb.FetchAll(ctx, s.taplisters, s.config.Fetch)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range results {
+		// Venues that fail keep their previous taplist, so one flaky
+		// site doesn't hold up updates from the others.
+		state := s.states[i]
+		wasFetched := state.Fetched()
+		prev := state.Taplist
+		d := state.Update(r, t)
+		s.metrics.recordFetch(state, r)
+		if state.LastError != nil {
+			log.Println(state.LastError)
+			continue
+		}
+		if !d.Empty() {
+			s.publishChange(prev, state.Taplist)
+			change := beerweb.TaplistChange{Time: t, TaplistDiff: d}
+			// A venue that's never been fetched has every beer added,
+			// which is no news to anybody.
+			if wasFetched {
+				s.notify(ctx, change)
+			}
+			if err := s.changes.Append(change); err != nil {
+				log.Println("error logging taplist change:", err)
+			}
+		}
+		err := s.history.Record(beerweb.Snapshot{Time: t, Taplist: state.Taplist})
+		if err != nil {
+			log.Println("error recording taplist:", err)
+		}
+		fetched++
+		totalBeers += len(state.Taplist.Beers)
+	}
+}
+
+// pollStarted records the start of a poll of the venues.
+func (s *Server) pollStarted(t time.Time) {
+	s.pollMu.Lock()
+	defer s.pollMu.Unlock()
+	s.lastPoll = t
+}
+
+// pollLag returns how long past due the next poll is as of now.
+func (s *Server) pollLag(now time.Time) time.Duration {
+	s.pollMu.Lock()
+	defer s.pollMu.Unlock()
+	if s.lastPoll.IsZero() {
+		return 0
+	}
+	if lag := now.Sub(s.lastPoll.Add(s.config.PollInterval)); lag > 0 {
+		return lag
+	}
+	return 0
+}
+
+// notify tells whoever's interested about a change to a taplist: the
+// webhooks if there are any, or else the log.
+func (s *Server) notify(ctx context.Context, c beerweb.TaplistChange) {
+	var alerts []beerweb.Alert
+	if s.config.Watcher != nil {
+		alerts = s.config.Watcher.Check(c.TaplistDiff, c.Time)
+	}
+	for _, a := range alerts {
+		log.Println("watch", a)
+	}
+	if s.config.Notifier == nil {
+		log.Println(c.TaplistDiff)
+		return
+	}
+	// Deliveries can take a while with retries, and shouldn't hold up
+	// the next venue.
+	go s.config.Notifier.Notify(ctx, webhook.NewPayload(c, alerts))
+}
+
+// sendDigests mails out a digest of the day's changes every day at the
+// configured time.
+func (s *Server) sendDigests(ctx context.Context, c digest.Config) {
+	for {
+		next := c.Next(time.Now())
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+		logged, err := s.changes.Changes()
+		if err != nil {
+			log.Println("error reading change log for digest:", err)
+			continue
+		}
+		d := digest.Summarize(logged, next.AddDate(0, 0, -1), next)
+		if err := c.Send(d); err != nil {
+			log.Println("error sending digest:", err)
+			continue
+		}
+		log.Printf("sent digest of changes at %d venues", len(d.Venues))
+	}
+}
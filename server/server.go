@@ -0,0 +1,210 @@
+// Package server serves taplists on the web, keeping them up to date by
+// polling the venues.
+//
+// A Server can be built from any Taplisters, so it can be tried out with
+// fakes:
+//
+//	s, err := server.New(venues, server.DefaultConfig())
+//	s.Poll(ctx)
+//	ts := httptest.NewServer(s.Handler())
+package server
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/digest"
+	"github.com/ianfoo/beerweb/store"
+	"github.com/ianfoo/beerweb/webhook"
+)
+
+// Config controls how a Server polls venues and serves requests. The
+// collaborators at the end are optional.
+type Config struct {
+	// Addr is the address to listen on. A bare port, like "5050", is
+	// taken to mean all interfaces.
+	Addr string
+	// PollInterval is how often to fetch the venues' taplists.
+	PollInterval time.Duration
+	// Fetch controls how each venue is fetched.
+	Fetch beerweb.FetchOptions
+	// ReadHeaderTimeout and WriteTimeout limit how long a client can take
+	// to send a request, and the server can take to respond.
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	// ReadyPolls, if more than 0, keeps the server from reporting ready
+	// while any venue hasn't been fetched successfully within that many
+	// polls.
+	ReadyPolls int
+
+	// Store records each successful fetch. It's kept in memory if nil.
+	Store beerweb.Store
+	// ChangeLog records changes to taplists. It's kept in memory if nil.
+	ChangeLog beerweb.ChangeLog
+	// Watcher raises alerts for changes, if it's set.
+	Watcher *beerweb.Watcher
+	// Notifier delivers changes to webhooks. Changes are only logged if
+	// it's nil.
+	Notifier *webhook.Notifier
+	// Digest configures a daily digest of changes, if it's set.
+	Digest *digest.Config
+}
+
+// DefaultConfig returns the configuration beerweb runs with unless it's told
+// otherwise.
+func DefaultConfig() Config {
+	return Config{
+		Addr:         ":5050",
+		PollInterval: 10 * time.Minute,
+		Fetch: beerweb.FetchOptions{
+			Timeout:    30 * time.Second,
+			Attempts:   2,
+			RetryDelay: 5 * time.Second,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+}
+
+// Server polls a set of venues and serves their taplists.
+type Server struct {
+	config     Config
+	taplisters []beerweb.Taplister
+	tmpl       *template.Template
+	metrics    *serverMetrics
+	events     *broker
+	started    time.Time
+
+	history beerweb.Store
+	changes beerweb.ChangeLog
+
+	mu     sync.RWMutex
+	states []*beerweb.VenueState
+
+	pollMu   sync.Mutex
+	lastPoll time.Time
+}
+
+// New returns a Server for the venues. Taplists are restored from the
+// configured store and change log, so there's something to show before the
+// first poll, and so the change log doesn't see every beer being added
+// again.
+func New(venues []beerweb.Taplister, c Config) (*Server, error) {
+	if c.PollInterval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+	if c.Addr != "" && !strings.Contains(c.Addr, ":") {
+		c.Addr = ":" + c.Addr
+	}
+	s := &Server{
+		config:     c,
+		taplisters: venues,
+		tmpl:       tmpl,
+		started:    time.Now(),
+		history:    c.Store,
+		changes:    c.ChangeLog,
+		states:     beerweb.NewVenueStates(venues),
+		events:     newBroker(),
+	}
+	if s.history == nil {
+		s.history = store.NewMemory()
+	}
+	if s.changes == nil {
+		s.changes = store.NewMemoryLog()
+	}
+	s.metrics = newServerMetrics(s)
+
+	snaps, err := s.history.Latest()
+	if err != nil {
+		return nil, err
+	}
+	logged, err := s.changes.Changes()
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range append(beerweb.Replay(logged), snaps...) {
+		for _, state := range s.states {
+			if state.Taplist.Venue == snap.Taplist.Venue && snap.Time.After(state.LastSuccess) {
+				state.Restore(snap)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Handler returns the handler for all of the server's pages and endpoints.
+func (s *Server) Handler() http.Handler {
+	m := http.NewServeMux()
+	m.HandleFunc("/", s.instrument("taplists", s.beerHandler))
+	m.HandleFunc("/beer", s.instrument("beer", s.beerHistoryHandler))
+	m.HandleFunc(apiPrefix, s.instrument("api", s.apiHandler))
+	m.HandleFunc("/events", s.instrument("events", s.eventsHandler))
+	m.Handle("/metrics", s.metrics.registry.Handler())
+	m.HandleFunc("/healthz", healthHandler)
+	m.HandleFunc("/readyz", s.readyHandler)
+	return m
+}
+
+// States returns a copy of the state of each venue.
+func (s *Server) States() []beerweb.VenueState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	states := make([]beerweb.VenueState, len(s.states))
+	for i, state := range s.states {
+		states[i] = *state
+	}
+	return states
+}
+
+// Close ends any event streams that are open, and refuses new ones, so that
+// the server can shut down. ListenAndServe takes care of this, but a Server
+// serving through some other http.Server needs it to be called.
+func (s *Server) Close() {
+	s.events.close()
+}
+
+// ListenAndServe polls the venues and serves their taplists on the
+// configured address until ctx is done, and then shuts down, abandoning any
+// fetches in flight.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	hs := &http.Server{
+		Addr:              s.config.Addr,
+		ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		MaxHeaderBytes:    1 << 20,
+		Handler:           s.Handler(),
+	}
+
+	pollCtx, cancelPoll := context.WithCancel(context.Background())
+	hs.RegisterOnShutdown(cancelPoll)
+	// Event streams never finish by themselves, so shutting down would
+	// wait on them forever.
+	hs.RegisterOnShutdown(s.Close)
+	go s.Run(pollCtx)
+	if s.config.Digest != nil {
+		go s.sendDigests(pollCtx, *s.config.Digest)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Println("listening on", hs.Addr)
+		errCh <- hs.ListenAndServe()
+	}()
+	select {
+	case err := <-errCh:
+		cancelPoll()
+		return err
+	case <-ctx.Done():
+	}
+	log.Println("shutting down")
+	err := hs.Shutdown(context.Background())
+	<-errCh
+	return err
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// fakeVenue is a Taplister with beers that can be changed between polls.
+type fakeVenue struct {
+	name string
+
+	mu    sync.Mutex
+	beers []beerweb.Beer
+	err   error
+}
+
+func (v *fakeVenue) FetchBeers(ctx context.Context) ([]beerweb.Beer, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.beers, v.err
+}
+
+func (v *fakeVenue) Venue() string { return v.name }
+func (v *fakeVenue) URL() string   { return "http://example.com/" + beerweb.Slug(v.name) }
+
+func (v *fakeVenue) set(beers []beerweb.Beer, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.beers, v.err = beers, err
+}
+
+var (
+	darkStar = beerweb.Beer{Brewery: "Fremont", Name: "Dark Star", Style: "Stout", ABV: beerweb.ParseABV("8%")}
+	lush     = beerweb.Beer{Brewery: "Fremont", Name: "Lush", Style: "IPA", ABV: beerweb.ParseABV("7%")}
+	pliny    = beerweb.Beer{Brewery: "Russian River", Name: "Pliny the Elder", Style: "DIPA"}
+)
+
+// newTestServer returns a server for a venue with beers, one that can't be
+// fetched, and a test server serving it. Nothing has been polled yet.
+func newTestServer(t *testing.T) (*Server, *fakeVenue, *httptest.Server) {
+	t.Helper()
+	local := &fakeVenue{name: "Local Pub", beers: []beerweb.Beer{darkStar, lush}}
+	broken := &fakeVenue{name: "Broken Bar", err: errors.New("site is down")}
+	c := DefaultConfig()
+	c.Fetch.Attempts = 1
+	s, err := New([]beerweb.Taplister{local, broken}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, local, ts
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestServer(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	s.Poll(context.Background())
+
+	tests := []struct {
+		path   string
+		status int
+		want   []string
+		not    []string
+	}{
+		{"/", http.StatusOK, []string{
+			"Beers at Local Pub",
+			`data-venue="local-pub"`,
+			`data-key="fremont|dark star"`,
+			"Beers at Broken Bar are unavailable",
+			"site is down",
+		}, nil},
+		{"/?q=lush", http.StatusOK, []string{"Lush"}, []string{"Dark Star"}},
+		{"/?min_abv=lots", http.StatusOK, []string{"ui error message"}, nil},
+		{"/api/v1/taplists", http.StatusOK, []string{`"venue":"Local Pub"`, `"name":"Dark Star"`, "site is down"}, nil},
+		{"/api/v1/venues/local-pub", http.StatusOK, []string{`"slug":"local-pub"`, `"name":"Lush"`}, nil},
+		{"/api/v1/venues/nowhere", http.StatusNotFound, []string{"no venue nowhere"}, nil},
+		{"/api/v1/beers", http.StatusOK, []string{`"venue_slug":"local-pub"`, `"name":"Dark Star"`}, nil},
+		{"/api/v1/nothing", http.StatusNotFound, nil, nil},
+		{"/healthz", http.StatusOK, []string{`"status":"ok"`}, nil},
+		{"/readyz", http.StatusOK, []string{`"ready":true`}, nil},
+		{"/metrics", http.StatusOK, []string{"beerweb_beers", "beerweb_fetches_total"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			status, body := get(t, ts.URL+tt.path)
+			if status != tt.status {
+				t.Errorf("status %d, want %d", status, tt.status)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(body, w) {
+					t.Errorf("body doesn't have %q:\n%s", w, body)
+				}
+			}
+			for _, n := range tt.not {
+				if strings.Contains(body, n) {
+					t.Errorf("body has %q", n)
+				}
+			}
+		})
+	}
+}
+
+func TestNotReadyBeforePoll(t *testing.T) {
+	_, _, ts := newTestServer(t)
+	status, body := get(t, ts.URL+"/readyz")
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want %d", status, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(body, "no venue has been fetched yet") {
+		t.Errorf("body doesn't say why it's not ready:\n%s", body)
+	}
+}
+
+func TestStaleVenue(t *testing.T) {
+	s, local, ts := newTestServer(t)
+	s.Poll(context.Background())
+	local.set(nil, errors.New("timed out"))
+	s.Poll(context.Background())
+
+	_, body := get(t, ts.URL+"/")
+	for _, w := range []string{"stale since", "timed out", "Dark Star"} {
+		if !strings.Contains(body, w) {
+			t.Errorf("page doesn't have %q:\n%s", w, body)
+		}
+	}
+	_, body = get(t, ts.URL+"/api/v1/venues/local-pub")
+	var v apiVenue
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Stale || !strings.Contains(v.Error, "timed out") || len(v.Beers) != 2 {
+		t.Errorf("got %+v, want the last beers, stale with the error", v)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s, local, ts := newTestServer(t)
+	s.Poll(context.Background())
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		var event string
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" && event != "" {
+				events <- event
+				event = ""
+				continue
+			}
+			event += line + "\n"
+		}
+	}()
+	next := func() string {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+		return ""
+	}
+	if e := next(); e != "retry: 10000\n" {
+		t.Fatalf("first event %q, want the retry delay", e)
+	}
+
+	changed := darkStar
+	changed.Style = "Imperial Stout"
+	local.set([]beerweb.Beer{changed, pliny}, nil)
+	s.Poll(context.Background())
+
+	e := next()
+	const prefix = "event: taplist\ndata: "
+	if !strings.HasPrefix(e, prefix) {
+		t.Fatalf("got %q, want a taplist event", e)
+	}
+	var te taplistEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(e, prefix)), &te); err != nil {
+		t.Fatal(err)
+	}
+	if te.Slug != "local-pub" {
+		t.Errorf("slug %q, want local-pub", te.Slug)
+	}
+	if got := rowKeys(te.Added); !equalKeys(got, []string{"russian river|pliny the elder"}) {
+		t.Errorf("added %v", got)
+	}
+	if !equalKeys(te.Removed, []string{"fremont|lush"}) {
+		t.Errorf("removed %v", te.Removed)
+	}
+	if len(te.Changed) != 1 || te.Changed[0].Cells["style"] != "Imperial Stout" {
+		t.Errorf("changed %+v, want Dark Star's new style", te.Changed)
+	}
+}
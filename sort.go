@@ -0,0 +1,131 @@
+package beerweb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SortKey is a field to sort beers by, like "brewery" or "abv".
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+func (k SortKey) String() string {
+	if k.Desc {
+		return "-" + k.Field
+	}
+	return k.Field
+}
+
+// ParseSortKeys parses a comma-separated list of fields to sort by, most
+// significant first. A field with a leading "-" is sorted in descending
+// order, so "brewery,-abv" lists each brewery's strongest beer first.
+func ParseSortKeys(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		var k SortKey
+		if strings.HasPrefix(f, "-") {
+			k.Desc = true
+			f = f[1:]
+		}
+		k.Field = strings.ToLower(f)
+		if _, ok := field(k.Field); !ok {
+			return nil, fmt.Errorf("can't sort by %q (fields are %s)", f, strings.Join(fieldNames(), ", "))
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// SortBeers sorts beers by the keys, keeping the original order of beers
+// that compare equal. Text is compared without regard to case, ABVs and tap
+// numbers by their value, and beers missing a field always sort after those
+// that have it.
+func SortBeers(beers []Beer, keys []SortKey) {
+	sort.SliceStable(beers, func(i, j int) bool {
+		for _, k := range keys {
+			c := compareField(k.Field, beers[i], beers[j])
+			if c == 0 {
+				continue
+			}
+			if k.Desc && c != missing && c != -missing {
+				c = -c
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// missing is what compareField returns when only one of the beers has the
+// field, so that SortBeers can keep it out of a descending sort's reversal.
+const missing = 2
+
+// compareField returns -1, 0 or 1 as a's field is less than, equal to or
+// greater than b's, or -missing or missing if b's or a's is missing.
+func compareField(name string, a, b Beer) int {
+	if name == "abv" {
+		switch {
+		case !a.ABV.Known() && !b.ABV.Known():
+			return 0
+		case !b.ABV.Known():
+			return -missing
+		case !a.ABV.Known():
+			return missing
+		}
+		return compareFloat(a.ABV.Value(), b.ABV.Value())
+	}
+
+	value, _ := field(name)
+	av, bv := value(a), value(b)
+	switch {
+	case av == "" && bv == "":
+		return 0
+	case bv == "":
+		return -missing
+	case av == "":
+		return missing
+	}
+	// Taps and IBUs are usually numbers, but not always.
+	if an, err := strconv.ParseFloat(av, 64); err == nil {
+		if bn, err := strconv.ParseFloat(bv, 64); err == nil {
+			return compareFloat(an, bn)
+		}
+	}
+	return strings.Compare(strings.ToLower(av), strings.ToLower(bv))
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// field returns the function that gets the named field of a beer as text.
+func field(name string) (func(Beer) string, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f.value, true
+		}
+	}
+	return nil, false
+}
+
+func fieldNames() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
@@ -0,0 +1,84 @@
+package beerweb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSortKeys(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []SortKey
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"abv", []SortKey{{Field: "abv"}}, ""},
+		{"Brewery, -ABV", []SortKey{{Field: "brewery"}, {Field: "abv", Desc: true}}, ""},
+		{"style,,name,", []SortKey{{Field: "style"}, {Field: "name"}}, ""},
+		{"brewery,color", nil, `can't sort by "color"`},
+		{"-", nil, `can't sort by ""`},
+	}
+	for _, tt := range tests {
+		got, err := ParseSortKeys(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSortKeys(%q) error %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSortKeys(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSortKeys(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSortBeers(t *testing.T) {
+	var (
+		lush     = Beer{Tap: "2", Brewery: "Fremont", Name: "Lush", Style: "IPA", ABV: ParseABV("7%")}
+		darkStar = Beer{Tap: "10", Brewery: "Fremont", Name: "Dark Star", Style: "stout", ABV: ParseABV("8%")}
+		pliny    = Beer{Tap: "1", Brewery: "Russian River", Name: "Pliny the Elder", Style: "DIPA", ABV: ParseABV("8%")}
+		cider    = Beer{Tap: "cask", Brewery: "Schilling", Name: "Excelsior", ABV: ParseABV("n/a")}
+		beers    = []Beer{lush, darkStar, pliny, cider}
+	)
+	tests := []struct {
+		keys string
+		want []string // names
+	}{
+		{"", []string{"Lush", "Dark Star", "Pliny the Elder", "Excelsior"}},
+		{"name", []string{"Dark Star", "Excelsior", "Lush", "Pliny the Elder"}},
+		{"-name", []string{"Pliny the Elder", "Lush", "Excelsior", "Dark Star"}},
+		// Styles are compared without regard to case, and the cider
+		// has none.
+		{"style", []string{"Pliny the Elder", "Lush", "Dark Star", "Excelsior"}},
+		{"-style", []string{"Dark Star", "Lush", "Pliny the Elder", "Excelsior"}},
+		// Beers without an ABV sort last either way, and ties keep their
+		// order.
+		{"abv", []string{"Lush", "Dark Star", "Pliny the Elder", "Excelsior"}},
+		{"-abv", []string{"Dark Star", "Pliny the Elder", "Lush", "Excelsior"}},
+		{"-abv,name", []string{"Dark Star", "Pliny the Elder", "Lush", "Excelsior"}},
+		{"-abv,-brewery", []string{"Pliny the Elder", "Dark Star", "Lush", "Excelsior"}},
+		{"brewery,-abv", []string{"Dark Star", "Lush", "Pliny the Elder", "Excelsior"}},
+		// Taps are compared as numbers when they are.
+		{"tap", []string{"Pliny the Elder", "Lush", "Dark Star", "Excelsior"}},
+	}
+	for _, tt := range tests {
+		keys, err := ParseSortKeys(tt.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted := append([]Beer(nil), beers...)
+		SortBeers(sorted, keys)
+		var got []string
+		for _, b := range sorted {
+			got = append(got, b.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sorted by %q got %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package beerweb
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoBeers is reported for a venue that returned an empty taplist after
+// previously having beers, which more likely means a broken scraper than a
+// venue that's run dry.
+var ErrNoBeers = errors.New("no beers found")
+
+// VenueState keeps the last good taplist for a venue, so that a failed
+// fetch doesn't lose it, along with how recent fetches have gone.
+type VenueState struct {
+	// Taplist is the taplist from the last successful fetch. It has
+	// the venue's name and URL even if there hasn't been one.
+	Taplist Taplist
+	// LastSuccess and LastAttempt are the times of the last successful
+	// fetch and of the last fetch, successful or not.
+	LastSuccess time.Time
+	LastAttempt time.Time
+	// LastError is the error from the last fetch, which is nil if it
+	// succeeded.
+	LastError error
+	// Failures counts the fetches that have failed since the last success.
+	Failures int
+}
+
+// NewVenueStates returns a state for each of the venues, none of which has
+// been fetched yet.
+func NewVenueStates(venues []Taplister) []*VenueState {
+	states := make([]*VenueState, len(venues))
+	for i, tl := range venues {
+		states[i] = &VenueState{Taplist: Taplist{Venue: tl.Venue(), URL: tl.URL()}}
+	}
+	return states
+}
+
+// Update records the result of fetching the venue at time t. The taplist is
+// only replaced if the fetch succeeded, and the returned diff describes the
+// change to it, if any.
+func (s *VenueState) Update(r Result, t time.Time) TaplistDiff {
+	s.LastAttempt = t
+	err := r.Err
+	if err == nil && len(r.Taplist.Beers) == 0 && len(s.Taplist.Beers) > 0 {
+		err = &FetchError{
+			Venue:    r.Taplist.Venue,
+			URL:      r.Taplist.URL,
+			Duration: r.Duration,
+			Attempts: r.Attempts,
+			Err:      ErrNoBeers,
+		}
+	}
+	if err != nil {
+		s.LastError = err
+		s.Failures++
+		return TaplistDiff{Venue: s.Taplist.Venue}
+	}
+
+	d := Diff(s.Taplist, r.Taplist)
+	s.Taplist = r.Taplist
+	s.LastSuccess = t
+	s.LastError = nil
+	s.Failures = 0
+	return d
+}
+
+// Restore sets the taplist from a previously recorded snapshot, as though it
+// had been fetched at the time of the snapshot. The venue's URL is kept, in
+// case it's changed since.
+func (s *VenueState) Restore(snap Snapshot) {
+	url := s.Taplist.URL
+	s.Taplist = snap.Taplist
+	s.Taplist.URL = url
+	s.LastSuccess = snap.Time
+}
+
+// Fetched reports whether the venue has ever been fetched successfully.
+func (s *VenueState) Fetched() bool {
+	return !s.LastSuccess.IsZero()
+}
+
+// Stale reports whether the taplist is out of date because the last fetch
+// failed. The taplist has been stale since LastSuccess.
+func (s *VenueState) Stale() bool {
+	return s.Fetched() && s.LastError != nil
+}
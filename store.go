@@ -0,0 +1,22 @@
+package beerweb
+
+import "time"
+
+// Snapshot is a venue's taplist as it was fetched at a particular time.
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	Taplist Taplist   `json:"taplist"`
+}
+
+// Store keeps a history of taplist snapshots. See package store for
+// implementations.
+type Store interface {
+	// Record saves a snapshot.
+	Record(Snapshot) error
+	// Latest returns the most recent snapshot of each venue.
+	Latest() ([]Snapshot, error)
+	// History returns every snapshot of a venue, oldest first.
+	History(venue string) ([]Snapshot, error)
+	// Close releases any resources held by the store.
+	Close() error
+}
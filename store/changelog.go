@@ -0,0 +1,84 @@
+package store
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// MemoryLog is a beerweb.ChangeLog that keeps everything in memory.
+type MemoryLog struct {
+	mu      sync.RWMutex
+	changes []beerweb.TaplistChange
+}
+
+// NewMemoryLog returns an empty MemoryLog.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+func (l *MemoryLog) Append(c beerweb.TaplistChange) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.changes = append(l.changes, c)
+	return nil
+}
+
+func (l *MemoryLog) Changes() ([]beerweb.TaplistChange, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]beerweb.TaplistChange(nil), l.changes...), nil
+}
+
+func (l *MemoryLog) Close() error {
+	return nil
+}
+
+// FileLog is a beerweb.ChangeLog that appends changes to a file as JSON, one
+// per line. Changes reads the file back.
+type FileLog struct {
+	mu   sync.Mutex
+	file *jsonLines
+}
+
+// OpenFileLog opens the change log in the named file, creating it if it
+// doesn't exist. As with OpenFile, a partially written last change is
+// discarded.
+func OpenFileLog(filename string) (*FileLog, error) {
+	file, err := openJSONLines(filename, func(data []byte) error {
+		var c beerweb.TaplistChange
+		return json.Unmarshal(data, &c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FileLog{file: file}, nil
+}
+
+func (l *FileLog) Append(c beerweb.TaplistChange) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.append(c)
+}
+
+func (l *FileLog) Changes() ([]beerweb.TaplistChange, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var changes []beerweb.TaplistChange
+	_, err := l.file.scan(func(data []byte) error {
+		var c beerweb.TaplistChange
+		if err := json.Unmarshal(data, &c); err != nil {
+			return err
+		}
+		changes = append(changes, c)
+		return nil
+	})
+	return changes, err
+}
+
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.close()
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// File is a beerweb.Store that appends snapshots to a file as JSON, one per
+// line, which makes it easy to inspect or trim with everyday tools. Only the
+// latest snapshot of each venue is kept in memory; History reads the file
+// back.
+type File struct {
+	mu     sync.Mutex
+	file   *jsonLines
+	latest map[string]beerweb.Snapshot
+}
+
+// OpenFile opens the store in the named file, creating it if it doesn't
+// exist. If the last snapshot in the file was only partially written, say
+// because of a crash, it's discarded.
+func OpenFile(filename string) (*File, error) {
+	s := &File{latest: make(map[string]beerweb.Snapshot)}
+	file, err := openJSONLines(filename, func(data []byte) error {
+		var snap beerweb.Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return err
+		}
+		s.latest[snap.Taplist.Venue] = snap
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+func (s *File) Record(snap beerweb.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.append(snap); err != nil {
+		return err
+	}
+	s.latest[snap.Taplist.Venue] = snap
+	return nil
+}
+
+func (s *File) Latest() ([]beerweb.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latest := make([]beerweb.Snapshot, 0, len(s.latest))
+	for _, snap := range s.latest {
+		latest = append(latest, snap)
+	}
+	sortByVenue(latest)
+	return latest, nil
+}
+
+func (s *File) History(venue string) ([]beerweb.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var snaps []beerweb.Snapshot
+	_, err := s.file.scan(func(data []byte) error {
+		var snap beerweb.Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return err
+		}
+		if snap.Taplist.Venue == venue {
+			snaps = append(snaps, snap)
+		}
+		return nil
+	})
+	return snaps, err
+}
+
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.close()
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// jsonLines is a file of JSON values, one per line, that's only appended to.
+// Callers are responsible for serializing access to it.
+type jsonLines struct {
+	f *os.File
+}
+
+// openJSONLines opens the named file, creating it if it doesn't exist, and
+// calls fn with each value in it. If the last value in the file was only
+// partially written, say because of a crash, it's discarded.
+func openJSONLines(filename string, fn func([]byte) error) (*jsonLines, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &jsonLines{f}
+	good, err := j.scan(fn)
+	if err == nil {
+		// Drop anything after the last complete value, so that new ones
+		// start on a line of their own.
+		err = f.Truncate(good)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("opening %s: %v", filename, err)
+	}
+	return j, nil
+}
+
+// scan calls fn for every value in the file, and returns the offset just
+// past the last complete one.
+func (j *jsonLines) scan(fn func([]byte) error) (int64, error) {
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var (
+		r    = bufio.NewReader(j.f)
+		good int64
+		line int
+	)
+	for {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Whatever is left without a newline is a partial write.
+			return good, nil
+		}
+		if err != nil {
+			return good, err
+		}
+		line++
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			if err := fn(trimmed); err != nil {
+				return good, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		good += int64(len(data))
+	}
+}
+
+func (j *jsonLines) append(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = j.f.Write(append(data, '\n'))
+	return err
+}
+
+func (j *jsonLines) close() error {
+	return j.f.Close()
+}
@@ -0,0 +1,57 @@
+// Package store provides implementations of beerweb.Store and
+// beerweb.ChangeLog, kept in memory or in files of JSON lines.
+package store
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// Memory is a beerweb.Store that keeps everything in memory, so it's gone
+// when the process exits.
+type Memory struct {
+	mu      sync.RWMutex
+	history map[string][]beerweb.Snapshot
+}
+
+// NewMemory returns an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{history: make(map[string][]beerweb.Snapshot)}
+}
+
+func (m *Memory) Record(s beerweb.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history[s.Taplist.Venue] = append(m.history[s.Taplist.Venue], s)
+	return nil
+}
+
+func (m *Memory) Latest() ([]beerweb.Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	latest := make([]beerweb.Snapshot, 0, len(m.history))
+	for _, snaps := range m.history {
+		latest = append(latest, snaps[len(snaps)-1])
+	}
+	sortByVenue(latest)
+	return latest, nil
+}
+
+func (m *Memory) History(venue string) ([]beerweb.Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snaps := m.history[venue]
+	return append([]beerweb.Snapshot(nil), snaps...), nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func sortByVenue(snaps []beerweb.Snapshot) {
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].Taplist.Venue < snaps[j].Taplist.Venue
+	})
+}
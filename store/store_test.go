@@ -0,0 +1,259 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+var start = time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func snapshot(hour int, venue string, names ...string) beerweb.Snapshot {
+	tl := beerweb.Taplist{Venue: venue}
+	for _, name := range names {
+		tl.Beers = append(tl.Beers, beerweb.Beer{Brewery: "Fremont", Name: name, ABV: beerweb.ParseABV("6.5%")})
+	}
+	return beerweb.Snapshot{Time: start.Add(time.Duration(hour) * time.Hour), Taplist: tl}
+}
+
+// venuesAt describes snapshots by venue and hour, which is easier to compare
+// than the snapshots themselves.
+func venuesAt(snaps []beerweb.Snapshot) []string {
+	var got []string
+	for _, s := range snaps {
+		got = append(got, s.Taplist.Venue+"@"+s.Time.Sub(start).String())
+	}
+	return got
+}
+
+func TestStores(t *testing.T) {
+	stores := []struct {
+		name string
+		open func(t *testing.T) beerweb.Store
+	}{
+		{"memory", func(t *testing.T) beerweb.Store { return NewMemory() }},
+		{"file", func(t *testing.T) beerweb.Store {
+			s, err := OpenFile(filepath.Join(t.TempDir(), "history.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			s := st.open(t)
+			defer s.Close()
+
+			if latest, err := s.Latest(); err != nil || len(latest) != 0 {
+				t.Errorf("new store has %v, %v", latest, err)
+			}
+			for _, snap := range []beerweb.Snapshot{
+				snapshot(0, "Pub", "Lush"),
+				snapshot(0, "Bar", "Dark Star"),
+				snapshot(1, "Pub", "Lush", "Dark Star"),
+				snapshot(2, "Pub"),
+			} {
+				if err := s.Record(snap); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			latest, err := s.Latest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := venuesAt(latest), []string{"Bar@0s", "Pub@2h0m0s"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("latest %v, want %v", got, want)
+			}
+			history, err := s.History("Pub")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := venuesAt(history), []string{"Pub@0s", "Pub@1h0m0s", "Pub@2h0m0s"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("history %v, want %v", got, want)
+			}
+			if history[1].Taplist.Beers[0].ABV.Value() != 6.5 {
+				t.Errorf("ABV wasn't kept: %+v", history[1].Taplist.Beers[0])
+			}
+			if history, err := s.History("Nowhere"); err != nil || len(history) != 0 {
+				t.Errorf("history of an unknown venue is %v, %v", history, err)
+			}
+		})
+	}
+}
+
+func TestFileReopen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Record(snapshot(0, "Pub", "Lush"))
+	s.Record(snapshot(1, "Pub", "Dark Star"))
+	s.Record(snapshot(1, "Bar", "Lush"))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	latest, _ := s.Latest()
+	if got, want := venuesAt(latest), []string{"Bar@1h0m0s", "Pub@1h0m0s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("latest after reopening %v, want %v", got, want)
+	}
+	if latest[1].Taplist.Beers[0].Name != "Dark Star" {
+		t.Errorf("latest Pub taplist is %v", latest[1].Taplist)
+	}
+
+	// New snapshots go after the old ones.
+	s.Record(snapshot(2, "Pub"))
+	history, _ := s.History("Pub")
+	if got, want := venuesAt(history), []string{"Pub@0s", "Pub@1h0m0s", "Pub@2h0m0s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("history %v, want %v", got, want)
+	}
+}
+
+func TestFileTruncatesPartialWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+	}{
+		{"cut off", `{"time":"2019-05-01T14:00:00Z","taplist":{"venue":"Pub","be`},
+		{"missing its newline", `{"time":"2019-05-01T14:00:00Z","taplist":{"venue":"Pub"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "history.jsonl")
+			s, err := OpenFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Record(snapshot(0, "Pub", "Lush"))
+			s.Close()
+			appendToFile(t, filename, tt.partial)
+
+			s, err = OpenFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			latest, _ := s.Latest()
+			if got, want := venuesAt(latest), []string{"Pub@0s"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("latest %v, want %v", got, want)
+			}
+			s.Record(snapshot(1, "Pub"))
+			s.Close()
+
+			// The partial snapshot is gone, and the next one starts on a
+			// line of its own.
+			data, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); len(lines) != 2 {
+				t.Errorf("file has %d lines, want 2:\n%s", len(lines), data)
+			}
+			s, err = OpenFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			history, _ := s.History("Pub")
+			if got, want := venuesAt(history), []string{"Pub@0s", "Pub@1h0m0s"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("history %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileCorrupt(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"time":"2019-05-01T12:00:00Z","taplist":{"venue":"Pub"}}` + "\n\nnot json\n"
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := OpenFile(filename)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("got %v, want an error on line 3", err)
+	}
+	// It's left alone for someone to fix.
+	if after, _ := ioutil.ReadFile(filename); string(after) != data {
+		t.Errorf("file was changed to %q", after)
+	}
+}
+
+func TestChangeLogs(t *testing.T) {
+	logs := []struct {
+		name string
+		open func(t *testing.T, filename string) beerweb.ChangeLog
+	}{
+		{"memory", func(t *testing.T, _ string) beerweb.ChangeLog { return NewMemoryLog() }},
+		{"file", func(t *testing.T, filename string) beerweb.ChangeLog {
+			l, err := OpenFileLog(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return l
+		}},
+	}
+	changes := []beerweb.TaplistChange{
+		{Time: start, TaplistDiff: beerweb.TaplistDiff{
+			Venue: "Pub",
+			Added: []beerweb.Beer{{Brewery: "Fremont", Name: "Lush"}},
+		}},
+		{Time: start.Add(time.Hour), TaplistDiff: beerweb.TaplistDiff{
+			Venue:   "Pub",
+			Removed: []beerweb.Beer{{Brewery: "Fremont", Name: "Lush"}},
+		}},
+	}
+	for _, tt := range logs {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "changes.jsonl")
+			l := tt.open(t, filename)
+			for _, c := range changes {
+				if err := l.Append(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := l.Changes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 || got[0].Venue != "Pub" || len(got[0].Added) != 1 || len(got[1].Removed) != 1 || !got[1].Time.Equal(changes[1].Time) {
+				t.Errorf("got changes %+v", got)
+			}
+			l.Close()
+
+			if tt.name != "file" {
+				return
+			}
+			appendToFile(t, filename, `{"time":`)
+			l = tt.open(t, filename)
+			defer l.Close()
+			if got, _ := l.Changes(); len(got) != 2 {
+				t.Errorf("reopened log has %d changes, want 2", len(got))
+			}
+		})
+	}
+}
+
+func appendToFile(t *testing.T, filename, s string) {
+	t.Helper()
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
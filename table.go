@@ -0,0 +1,422 @@
+package beerweb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// beerField is an attribute of a beer that can be shown as a column of a table,
+// or written by the flat formats.
+type beerField struct {
+	name, title string
+	value       func(Beer) string
+	// numeric fields are right-aligned in tables.
+	numeric bool
+}
+
+// fields lists the fields of a beer in the order they're usually shown.
+var fields = []beerField{
+	{"tap", "Tap", func(b Beer) string { return b.Tap }, true},
+	{"brewery", "Brewery", func(b Beer) string { return b.Brewery }, false},
+	{"name", "Name", func(b Beer) string { return b.Name }, false},
+	{"style", "Style", func(b Beer) string { return b.Style }, false},
+	{"abv", "ABV", func(b Beer) string { return b.ABV.String() }, true},
+	{"ibu", "IBU", func(b Beer) string { return b.IBU }, true},
+	{"origin", "Origin", func(b Beer) string { return b.Origin }, false},
+	{"serving", "Serving", func(b Beer) string { return b.Serving }, false},
+	{"price", "Price", Beer.PriceList, false},
+}
+
+// ParseColumns parses a comma-separated list of fields to show as the
+// columns of a TextTable, like "brewery,name,abv".
+func ParseColumns(s string) ([]string, error) {
+	var columns []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := field(name); !ok {
+			return nil, fmt.Errorf("no column %q (columns are %s)", name, strings.Join(fieldNames(), ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// Rule is a line across a TextTable: Left and Right at the ends, Sep where
+// it crosses a column separator, and Fill everywhere else. A Rule with no
+// Fill isn't drawn.
+type Rule struct {
+	Left, Fill, Sep, Right string
+}
+
+// Border is a style of lines to draw a TextTable with.
+type Border struct {
+	Top, Header, Bottom Rule
+	// Left, Sep and Right separate the cells of a row, and Pad goes on
+	// either side of each cell's value.
+	Left, Sep, Right, Pad string
+
+	// alignMarks puts a colon at the end of the header rule of
+	// right-aligned columns, as in Markdown.
+	alignMarks bool
+	escape     *strings.Replacer
+}
+
+// The borders a TextTable can be drawn with.
+var (
+	ASCIIBorder = Border{
+		Top:    Rule{"=", "=", "=", "="},
+		Header: Rule{"=", "=", "=", "="},
+		Bottom: Rule{"=", "=", "=", "="},
+		Left:   "|", Sep: "|", Right: "|", Pad: " ",
+	}
+	UnicodeBorder = Border{
+		Top:    Rule{"┌", "─", "┬", "┐"},
+		Header: Rule{"├", "─", "┼", "┤"},
+		Bottom: Rule{"└", "─", "┴", "┘"},
+		Left:   "│", Sep: "│", Right: "│", Pad: " ",
+	}
+	NoBorder = Border{Sep: "  "}
+	// MarkdownBorder makes a table that's also a GitHub-flavored Markdown
+	// table, escaping any pipes in the beers' fields.
+	MarkdownBorder = Border{
+		Header: Rule{"|", "-", "|", "|"},
+		Left:   "|", Sep: "|", Right: "|", Pad: " ",
+		alignMarks: true,
+		escape:     strings.NewReplacer("|", `\|`),
+	}
+)
+
+// ParseBorder returns the border with the given name: ascii, unicode, none
+// or markdown.
+func ParseBorder(name string) (Border, error) {
+	switch strings.ToLower(name) {
+	case "ascii":
+		return ASCIIBorder, nil
+	case "unicode":
+		return UnicodeBorder, nil
+	case "none":
+		return NoBorder, nil
+	case "markdown":
+		return MarkdownBorder, nil
+	}
+	return Border{}, fmt.Errorf("unknown border %q (borders are ascii, unicode, none and markdown)", name)
+}
+
+// DefaultStrongABV is the ABV from which beers are highlighted in tables
+// with Color set, unless they say otherwise.
+const DefaultStrongABV = 8.0
+
+// ANSI escape sequences for TextTable's colors.
+const (
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiReset = "\x1b[0m"
+)
+
+// TextTable renders a list of beers entry in compliance with the configured
+// field widths.
+type TextTable struct {
+	beers []Beer
+
+	TapWidth,
+	BreweryWidth,
+	NameWidth,
+	StyleWidth,
+	ABVWidth,
+	IBUWidth,
+	OriginWidth,
+	ServingWidth,
+	PriceWidth int
+
+	// Columns names the fields to show, in order, like "brewery" or "abv".
+	// If it's nil, every field is shown that any of the beers have.
+	Columns []string
+	// Border is the style of lines the table is drawn with. The zero
+	// Border means ASCIIBorder.
+	Border Border
+	// Color makes the header bold, and beers of at least StrongABV red,
+	// using ANSI escape sequences. StrongABV defaults to
+	// DefaultStrongABV.
+	Color     bool
+	StrongABV float64
+
+	// MaxWidth, if more than zero, limits the width of the table. The
+	// widest columns are narrowed until it fits, and values that don't fit
+	// are cut short, or wrapped onto more lines if Wrap is set.
+	MaxWidth int
+	Wrap     bool
+}
+
+// column is a field shown in a TextTable, with the width of its widest
+// value.
+type column struct {
+	beerField
+	width int
+}
+
+// widthOf returns the width field of the TextTable for the named field.
+func (tt *TextTable) widthOf(name string) *int {
+	switch name {
+	case "tap":
+		return &tt.TapWidth
+	case "brewery":
+		return &tt.BreweryWidth
+	case "name":
+		return &tt.NameWidth
+	case "style":
+		return &tt.StyleWidth
+	case "abv":
+		return &tt.ABVWidth
+	case "ibu":
+		return &tt.IBUWidth
+	case "origin":
+		return &tt.OriginWidth
+	case "serving":
+		return &tt.ServingWidth
+	case "price":
+		return &tt.PriceWidth
+	}
+	return nil
+}
+
+// columns returns the columns to show. Unless they're chosen with Columns,
+// those that are empty for every beer are left out.
+func (tt *TextTable) columns() []column {
+	var columns []column
+	if tt.Columns == nil {
+		for _, f := range fields {
+			if w := tt.columnWidth(f); w > 0 {
+				columns = append(columns, column{f, w})
+			}
+		}
+		return columns
+	}
+	for _, name := range tt.Columns {
+		for _, f := range fields {
+			if f.name == name {
+				columns = append(columns, column{f, tt.columnWidth(f)})
+			}
+		}
+	}
+	return columns
+}
+
+// columnWidth returns the width of a field's widest value, as shown with the
+// table's border, or its configured width if that's wider.
+func (tt *TextTable) columnWidth(f beerField) int {
+	w := *tt.widthOf(f.name)
+	if tt.border().escape == nil {
+		return w
+	}
+	for _, b := range tt.beers {
+		if l := DisplayWidth(tt.text(f, b)); l > w {
+			w = l
+		}
+	}
+	return w
+}
+
+// Add saves a beer entry, and compares a its field widths to the
+// currently-stored format widths, and updates any of them that are shorter
+// that the current beer's field widths.
+func (tt *TextTable) Add(b Beer) {
+	tt.beers = append(tt.beers, b)
+	for _, f := range fields {
+		if w, l := tt.widthOf(f.name), DisplayWidth(f.value(b)); l > *w {
+			*w = l
+		}
+	}
+}
+
+// NewTextTable builds a model for a nicely-formatted text table based, given
+// a slice of Beer values.
+func NewTextTable(beers []Beer) *TextTable {
+	table := &TextTable{}
+	for _, beer := range beers {
+		table.Add(beer)
+	}
+	return table
+}
+
+func (tt *TextTable) border() Border {
+	if tt.Border == (Border{}) {
+		return ASCIIBorder
+	}
+	return tt.Border
+}
+
+// text returns a field of a beer as it's shown in the table.
+func (tt *TextTable) text(f beerField, b Beer) string {
+	if e := tt.border().escape; e != nil {
+		return e.Replace(f.value(b))
+	}
+	return f.value(b)
+}
+
+// minColumnWidth is as narrow as a column gets to fit MaxWidth, which leaves
+// room for a character and an ellipsis.
+const minColumnWidth = 3
+
+// layout returns the width each column is displayed at. Columns are at least
+// as wide as their titles, unless they're narrowed to fit MaxWidth.
+func (tt *TextTable) layout(columns []column) []int {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = column.width
+		if l := DisplayWidth(column.title); l > widths[i] {
+			widths[i] = l
+		}
+	}
+	for total := tt.width(widths); tt.MaxWidth > 0 && total > tt.MaxWidth; total-- {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+	}
+	return widths
+}
+
+// width returns the width of a table with columns of the given widths.
+func (tt *TextTable) width(widths []int) int {
+	b := tt.border()
+	width := DisplayWidth(b.Left) + DisplayWidth(b.Right)
+	for i, w := range widths {
+		if i > 0 {
+			width += DisplayWidth(b.Sep)
+		}
+		width += w + 2*DisplayWidth(b.Pad)
+	}
+	return width
+}
+
+// cell returns the lines a value takes up in a column of the given width.
+func (tt *TextTable) cell(value string, width int) []string {
+	if DisplayWidth(value) <= width {
+		return []string{value}
+	}
+	if tt.Wrap {
+		return WrapWidth(value, width)
+	}
+	return []string{TruncateWidth(value, width)}
+}
+
+// rule draws a line across the table, if the rule has one.
+func (tt *TextTable) rule(s *strings.Builder, r Rule, columns []column, widths []int) {
+	if r.Fill == "" {
+		return
+	}
+	pad := 2 * DisplayWidth(tt.border().Pad)
+	s.WriteString(r.Left)
+	for i, w := range widths {
+		if i > 0 {
+			s.WriteString(r.Sep)
+		}
+		if tt.border().alignMarks && columns[i].numeric {
+			s.WriteString(strings.Repeat(r.Fill, w+pad-1) + ":")
+		} else {
+			s.WriteString(strings.Repeat(r.Fill, w+pad))
+		}
+	}
+	s.WriteString(r.Right)
+	s.WriteByte('\n')
+}
+
+// row draws a line of the table with the given cells, in color if it's not
+// empty.
+func (tt *TextTable) row(s *strings.Builder, cells []string, columns []column, widths []int, color string) {
+	var (
+		b    = tt.border()
+		line strings.Builder
+	)
+	line.WriteString(b.Left)
+	for i, value := range cells {
+		if i > 0 {
+			line.WriteString(b.Sep)
+		}
+		padding := strings.Repeat(" ", widths[i]-DisplayWidth(value))
+		if color != "" && value != "" {
+			value = color + value + ansiReset
+		}
+		line.WriteString(b.Pad)
+		if columns[i].numeric {
+			line.WriteString(padding + value)
+		} else {
+			line.WriteString(value + padding)
+		}
+		line.WriteString(b.Pad)
+	}
+	line.WriteString(b.Right)
+	// Without a border on the right, the padding of the last column is
+	// just trailing space.
+	s.WriteString(strings.TrimRight(line.String(), " "))
+	s.WriteByte('\n')
+}
+
+// Format renders the fields of a Beer using a minimum width for each field,
+// bordered by spaces and a pipe character. This is useful for writing a list
+// of beers as a table that can be easily read.
+func (tt TextTable) String() string {
+	var (
+		s       strings.Builder
+		border  = tt.border()
+		columns = tt.columns()
+		widths  = tt.layout(columns)
+		cells   = make([]string, len(columns))
+		color   string
+	)
+	tt.rule(&s, border.Top, columns, widths)
+	for i, column := range columns {
+		cells[i] = TruncateWidth(column.title, widths[i])
+	}
+	if tt.Color {
+		color = ansiBold
+	}
+	tt.row(&s, cells, columns, widths, color)
+	tt.rule(&s, border.Header, columns, widths)
+
+	strong := tt.StrongABV
+	if strong == 0 {
+		strong = DefaultStrongABV
+	}
+	lines := make([][]string, len(columns))
+	for _, b := range tt.beers {
+		color = ""
+		if tt.Color && b.ABV.Known() && b.ABV.Value() >= strong {
+			color = ansiRed
+		}
+		// A wrapped value makes the whole row taller.
+		height := 1
+		for i, column := range columns {
+			lines[i] = tt.cell(tt.text(column.beerField, b), widths[i])
+			if len(lines[i]) > height {
+				height = len(lines[i])
+			}
+		}
+		for line := 0; line < height; line++ {
+			for i := range columns {
+				cells[i] = ""
+				if line < len(lines[i]) {
+					cells[i] = lines[i][line]
+				}
+			}
+			tt.row(&s, cells, columns, widths, color)
+		}
+	}
+	tt.rule(&s, border.Bottom, columns, widths)
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// Width returns the entire with of a formatted row, including space padding
+// and separator characters.
+func (tt TextTable) Width() int {
+	return tt.width(tt.layout(tt.columns()))
+}
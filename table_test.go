@@ -0,0 +1,154 @@
+package beerweb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var tableBeers = []Beer{
+	{Tap: "1", Brewery: "Fremont", Name: "Dark Star", ABV: ParseABV("8%")},
+	{Tap: "12", Brewery: "Holy Mountain", Name: "Kiln & Cask", ABV: ParseABV("5.2")},
+}
+
+func TestTextTableBorders(t *testing.T) {
+	tests := []struct {
+		border string
+		want   string
+	}{
+		{"ascii", `
+============================================
+| Tap | Brewery       | Name        |  ABV |
+============================================
+|   1 | Fremont       | Dark Star   |   8% |
+|  12 | Holy Mountain | Kiln & Cask | 5.2% |
+============================================`},
+		{"unicode", `
+┌─────┬───────────────┬─────────────┬──────┐
+│ Tap │ Brewery       │ Name        │  ABV │
+├─────┼───────────────┼─────────────┼──────┤
+│   1 │ Fremont       │ Dark Star   │   8% │
+│  12 │ Holy Mountain │ Kiln & Cask │ 5.2% │
+└─────┴───────────────┴─────────────┴──────┘`},
+		{"none", `
+Tap  Brewery        Name          ABV
+  1  Fremont        Dark Star      8%
+ 12  Holy Mountain  Kiln & Cask  5.2%`},
+		{"Markdown", `
+| Tap | Brewery       | Name        |  ABV |
+|----:|---------------|-------------|-----:|
+|   1 | Fremont       | Dark Star   |   8% |
+|  12 | Holy Mountain | Kiln & Cask | 5.2% |`},
+	}
+	for _, tt := range tests {
+		border, err := ParseBorder(tt.border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table := NewTextTable(tableBeers)
+		table.Border = border
+		if got, want := table.String(), strings.TrimPrefix(tt.want, "\n"); got != want {
+			t.Errorf("%s border got\n%s\nwant\n%s", tt.border, got, want)
+		}
+	}
+	if _, err := ParseBorder("double"); err == nil {
+		t.Error("ParseBorder accepted an unknown border")
+	}
+	// The zero Border is ASCII.
+	if got, want := NewTextTable(tableBeers).String(), strings.TrimPrefix(tests[0].want, "\n"); got != want {
+		t.Errorf("default border got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTextTableColumns(t *testing.T) {
+	tests := []struct {
+		columns string
+		want    string
+	}{
+		// A column that's chosen is shown even if it's empty, and
+		// numbers are right-aligned wherever they are.
+		{"name,abv,style", `
+==============================
+| Name        |  ABV | Style |
+==============================
+| Dark Star   |   8% |       |
+| Kiln & Cask | 5.2% |       |
+==============================`},
+		{" ABV , tap", `
+==============
+|  ABV | Tap |
+==============
+|   8% |   1 |
+| 5.2% |  12 |
+==============`},
+	}
+	for _, tt := range tests {
+		columns, err := ParseColumns(tt.columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table := NewTextTable(tableBeers)
+		table.Columns = columns
+		if got, want := table.String(), strings.TrimPrefix(tt.want, "\n"); got != want {
+			t.Errorf("columns %q got\n%s\nwant\n%s", tt.columns, got, want)
+		}
+	}
+
+	if got, err := ParseColumns("brewery,,name,"); err != nil || !reflect.DeepEqual(got, []string{"brewery", "name"}) {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := ParseColumns("name,color"); err == nil || !strings.Contains(err.Error(), `no column "color"`) {
+		t.Errorf("got %v, want an error about the unknown column", err)
+	}
+}
+
+func TestTextTableColor(t *testing.T) {
+	const (
+		bold  = "\x1b[1m"
+		red   = "\x1b[31m"
+		reset = "\x1b[0m"
+	)
+	tests := []struct {
+		name      string
+		color     bool
+		strongABV float64
+		want      []string
+	}{
+		{"off", false, 0, []string{
+			"| Name        |  ABV |",
+			"| Dark Star   |   8% |",
+			"| Kiln & Cask | 5.2% |",
+			"| Mystery     |      |",
+		}},
+		// Padding goes outside the colors, so columns still line up, and
+		// a beer with no ABV is never strong.
+		{"on", true, 0, []string{
+			"| " + bold + "Name" + reset + "        |  " + bold + "ABV" + reset + " |",
+			"| " + red + "Dark Star" + reset + "   |   " + red + "8%" + reset + " |",
+			"| Kiln & Cask | 5.2% |",
+			"| Mystery     |      |",
+		}},
+		{"strong at 5%", true, 5, []string{
+			"| " + bold + "Name" + reset + "        |  " + bold + "ABV" + reset + " |",
+			"| " + red + "Dark Star" + reset + "   |   " + red + "8%" + reset + " |",
+			"| " + red + "Kiln & Cask" + reset + " | " + red + "5.2%" + reset + " |",
+			"| Mystery     |      |",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := NewTextTable(append(tableBeers, Beer{Name: "Mystery"}))
+			table.Columns = []string{"name", "abv"}
+			table.Color, table.StrongABV = tt.color, tt.strongABV
+			var got []string
+			for _, line := range strings.Split(table.String(), "\n") {
+				if strings.HasPrefix(line, "|") {
+					got = append(got, line)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package beerweb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the functions available to templates given to
+// NewTemplateRenderer, besides the ones built in to text/template:
+//
+//	percent   formats an ABV or a number as a percentage, like "6.5%"
+//	pad       pads text with spaces to a width, on the left if it's negative
+//	truncate  shortens text to a width, ending it with "…" if it's cut
+//	upper     converts text to upper case
+//	lower     converts text to lower case
+//
+// The text for pad and truncate comes last, so they can be used in
+// pipelines like {{.Name | truncate 20 | pad 20}}. Widths are in terminal
+// columns, as counted by DisplayWidth.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"percent":  templatePercent,
+		"pad":      templatePad,
+		"truncate": templateTruncate,
+		"upper":    func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+		"lower":    func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+	}
+}
+
+func templatePercent(v interface{}) string {
+	switch v := v.(type) {
+	case ABV:
+		return v.String()
+	case float64:
+		return ABVPercent(v).String()
+	case int:
+		return ABVPercent(float64(v)).String()
+	case string:
+		return ParseABV(v).String()
+	}
+	return fmt.Sprint(v)
+}
+
+func templatePad(width int, v interface{}) string {
+	s := fmt.Sprint(v)
+	right := width < 0
+	if right {
+		width = -width
+	}
+	n := width - DisplayWidth(s)
+	if n <= 0 {
+		return s
+	}
+	if right {
+		return strings.Repeat(" ", n) + s
+	}
+	return s + strings.Repeat(" ", n)
+}
+
+func templateTruncate(width int, v interface{}) string {
+	return TruncateWidth(fmt.Sprint(v), width)
+}
+
+// templateBeer is what a template is executed with for each beer: the beer's
+// fields, and the Venue and URL of its taplist.
+type templateBeer struct {
+	Venue string
+	URL   string
+	Beer
+}
+
+// templateRenderer executes a template for each beer, or for each taplist.
+type templateRenderer struct {
+	tmpl       *template.Template
+	perTaplist bool
+}
+
+// NewTemplateRenderer returns a Renderer that executes a text/template for
+// each beer on the taplists, with the fields of the beer and the Venue and
+// URL of its taplist, like
+//
+//	{{.Venue}}: {{.Brewery}} {{.Name}} {{.ABV}}
+//
+// If perTaplist is true, the template is executed with each Taplist instead.
+// Each execution's output gets a line of its own. The functions from
+// TemplateFuncs are available.
+func NewTemplateRenderer(text string, perTaplist bool) (Renderer, error) {
+	tmpl, err := template.New("beerlist").Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return templateRenderer{tmpl, perTaplist}, nil
+}
+
+func (templateRenderer) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (r templateRenderer) Render(w io.Writer, taplists []Taplist) error {
+	var buf bytes.Buffer
+	execute := func(data interface{}) error {
+		buf.Reset()
+		if err := r.tmpl.Execute(&buf, data); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	for _, tl := range taplists {
+		if r.perTaplist {
+			if err := execute(tl); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, b := range tl.Beers {
+			if err := execute(templateBeer{tl.Venue, tl.URL, b}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package beerweb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{percent .ABV}}`, "8%"},
+		{`{{percent 6.5}}`, "6.5%"},
+		{`{{percent 7}}`, "7%"},
+		{`{{percent "ABV 5.2"}}`, "5.2%"},
+		{`{{percent "n/a"}}`, "n/a"},
+		{`{{percent true}}`, "true"},
+		{`[{{pad 6 .Name}}]`, "[Lush  ]"},
+		{`[{{pad -6 .Name}}]`, "[  Lush]"},
+		{`[{{pad 2 .Name}}]`, "[Lush]"},
+		{`[{{pad 4 "日本"}}]`, "[日本]"},
+		{`[{{.ABV | pad -4}}]`, "[  8%]"},
+		{`{{truncate 3 .Name}}`, "Lu…"},
+		{`{{truncate 10 .Name}}`, "Lush"},
+		{`[{{.Brewery | truncate 5 | pad 6}}]`, "[Free… ]"},
+		{`{{upper .Name}}`, "LUSH"},
+		{`{{lower .Brewery}}`, "freemont"},
+		{`{{upper .ABV}}`, "8%"},
+	}
+	b := Beer{Tap: "12", Brewery: "Freemont", Name: "Lush", ABV: ParseABV("8%")}
+	for _, tt := range tests {
+		r, err := NewTemplateRenderer(tt.text, false)
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		var s strings.Builder
+		if err := r.Render(&s, []Taplist{{Venue: "Pub", Beers: []Beer{b}}}); err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if got := strings.TrimSuffix(s.String(), "\n"); got != tt.want {
+			t.Errorf("%s got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	taplists := []Taplist{
+		{Venue: "Pub", URL: "http://example.com", Beers: []Beer{
+			{Brewery: "Fremont", Name: "Lush"},
+			{Brewery: "Fremont", Name: "Dark Star"},
+		}},
+		{Venue: "Empty"},
+	}
+	tests := []struct {
+		text       string
+		perTaplist bool
+		want       string
+	}{
+		{"{{.Venue}}: {{.Name}}", false, "Pub: Lush\nPub: Dark Star\n"},
+		// Output that already ends a line doesn't get another.
+		{"{{.URL}} {{.Name}}\n", false, "http://example.com Lush\nhttp://example.com Dark Star\n"},
+		{"{{.Venue}} has {{len .Beers}}", true, "Pub has 2\nEmpty has 0\n"},
+		{"{{range .Beers}}{{.Name}}\n{{end}}", true, "Lush\nDark Star\n\n"},
+	}
+	for _, tt := range tests {
+		r, err := NewTemplateRenderer(tt.text, tt.perTaplist)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s strings.Builder
+		if err := r.Render(&s, taplists); err != nil {
+			t.Errorf("%q: %v", tt.text, err)
+			continue
+		}
+		if got := s.String(); got != tt.want {
+			t.Errorf("%q got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for _, text := range []string{"{{.Name", "{{nosuchfunc .Name}}", "{{pad .Name}}{{end}}"} {
+		if _, err := NewTemplateRenderer(text, false); err == nil {
+			t.Errorf("NewTemplateRenderer(%q) didn't fail", text)
+		}
+	}
+
+	// Fields that aren't there are found out when the template's executed.
+	r, err := NewTemplateRenderer("{{.Color}}", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s strings.Builder
+	err = r.Render(&s, []Taplist{{Venue: "Pub", Beers: []Beer{{Name: "Lush"}}}})
+	if err == nil || !strings.Contains(err.Error(), "Color") {
+		t.Errorf("got %v, want an error about the missing field", err)
+	}
+}
@@ -0,0 +1,257 @@
+package venues
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianfoo/beerweb"
+	"github.com/ianfoo/beerweb/html"
+)
+
+// A venues file is a JSON array of venues, like
+//
+//	[
+//	  {
+//	    "name": "Chuck's Hop Shop (Greenwood)",
+//	    "url": "http://chucks.jjshanks.net/draft",
+//	    "type": "html-table",
+//	    "selectors": {
+//	      "table": "div[id=draft_list] > table",
+//	      "brewery": "td.draft_brewery",
+//	      "name": "td.draft_name"
+//	    }
+//	  }
+//	]
+//
+// The type names a fetcher registered with beerweb.RegisterFetcher, and
+// defaults to html-table. Everything other than the name, url and type is
+// passed along to the fetcher as options.
+//
+// Only JSON is supported for now. YAML and TOML would need a parser that
+// reports lines the way encoding/json's offsets let us, and neither is in
+// the standard library, so files with their extensions are refused rather
+// than misread as JSON.
+
+const defaultType = html.FetcherKind
+
+type venueConfig struct {
+	Name    string
+	URL     string
+	Type    string
+	Options beerweb.Options
+}
+
+func (vc *venueConfig) UnmarshalJSON(data []byte) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		key string
+		val *string
+	}{
+		{"name", &vc.Name},
+		{"url", &vc.URL},
+		{"type", &vc.Type},
+	} {
+		v, ok := fields[f.key]
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", f.key)
+		}
+		*f.val = s
+		delete(fields, f.key)
+	}
+	vc.Options = fields
+	return nil
+}
+
+// ConfigError describes a problem with a venues file, and where in the file
+// it is.
+type ConfigError struct {
+	File   string
+	Line   int
+	Column int
+	Venue  string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	file := e.File
+	if file == "" {
+		file = "venues"
+	}
+	if e.Venue != "" {
+		return fmt.Sprintf("%s:%d:%d: venue %q: %v", file, e.Line, e.Column, e.Venue, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", file, e.Line, e.Column, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// LoadFile reads venues from the named file. See Load.
+func LoadFile(filename string) ([]beerweb.Taplister, error) {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".yaml", ".yml", ".toml":
+		return nil, fmt.Errorf("%s: %s venues files aren't supported, only JSON", filename, ext[1:])
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	taplisters, err := Load(f)
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) {
+		cfgErr.File = filename
+	}
+	return taplisters, err
+}
+
+// Load reads a venues file and builds a Taplister for each venue in it. All
+// venues are checked before anything is returned, and problems are reported
+// as a *ConfigError with the line and column they were found at. Each venue
+// needs a name with a distinct Slug.
+func Load(r io.Reader) ([]beerweb.Taplister, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, jsonError(data, 0, err)
+	} else if tok != json.Delim('[') {
+		return nil, configError(data, 0, "", errors.New("expected an array of venues"))
+	}
+
+	var (
+		taplisters []beerweb.Taplister
+		seen       = make(map[string]string)
+	)
+	for dec.More() {
+		start := skipSeparators(data, dec.InputOffset())
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, jsonError(data, 0, err)
+		}
+
+		var vc venueConfig
+		if err := json.Unmarshal(raw, &vc); err != nil {
+			return nil, jsonError(data, start, err)
+		}
+		if err := vc.validate(); err != nil {
+			return nil, configError(data, start, vc.Name, err)
+		}
+		// Venues are addressed by slug in the API, so two names that
+		// only differ in punctuation, like "Chuck's" and "Chucks", would
+		// be one venue as far as it's concerned.
+		slug := beerweb.Slug(vc.Name)
+		if slug == "" {
+			return nil, configError(data, start, vc.Name, errors.New("name needs a letter or digit"))
+		}
+		if other, ok := seen[slug]; ok {
+			return nil, configError(data, start, vc.Name,
+				fmt.Errorf("duplicate venue: %q has the same slug, %q", other, slug))
+		}
+		seen[slug] = vc.Name
+		tl, err := beerweb.NewTaplister(vc.Type, beerweb.VenueConfig{
+			Name:    vc.Name,
+			URL:     vc.URL,
+			Options: vc.Options,
+		})
+		if err != nil {
+			return nil, configError(data, start, vc.Name, err)
+		}
+		taplisters = append(taplisters, tl)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonError(data, 0, err)
+	}
+	return taplisters, nil
+}
+
+func (vc *venueConfig) validate() error {
+	switch {
+	case vc.Name == "":
+		return errors.New("missing name")
+	case vc.URL == "":
+		return errors.New("missing url")
+	}
+	if vc.Type == "" {
+		vc.Type = defaultType
+	}
+	u, err := url.Parse(vc.URL)
+	if err != nil {
+		return fmt.Errorf("bad url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("bad url %q: must be http or https", vc.URL)
+	}
+	return nil
+}
+
+// jsonError converts an error from encoding/json to a *ConfigError if it
+// carries an offset, which is relative to base.
+func jsonError(data []byte, base int64, err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		// The offset is just past the character that was wrong.
+		offset := syntaxErr.Offset
+		if offset > 0 {
+			offset--
+		}
+		return configError(data, base+offset, "", err)
+	case errors.As(err, &typeErr):
+		return configError(data, base+typeErr.Offset, "", err)
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return configError(data, int64(len(data)), "", errors.New("unexpected end of file"))
+	}
+	return configError(data, base, "", err)
+}
+
+func configError(data []byte, offset int64, venue string, err error) error {
+	line, col := position(data, offset)
+	return &ConfigError{Line: line, Column: col, Venue: venue, Err: err}
+}
+
+// position converts a byte offset into a one-based line and column.
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte{'\n'}) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// skipSeparators advances offset past any whitespace and commas, which
+// json.Decoder.InputOffset doesn't count as part of the next value.
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
@@ -0,0 +1,109 @@
+package venues
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestLoadDuplicateSlugs(t *testing.T) {
+	const file = `[
+  {"name": "Chuck's", "url": "http://example.com/a", "selectors": {"table": "table", "brewery": "td.b", "name": "td.n"}},
+  {"name": "Chucks", "url": "http://example.com/b", "selectors": {"table": "table", "brewery": "td.b", "name": "td.n"}}
+]`
+	_, err := Load(strings.NewReader(file))
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("got %v, want a *ConfigError", err)
+	}
+	if cfgErr.Line != 3 || cfgErr.Venue != "Chucks" {
+		t.Errorf("got error at line %d for venue %q, want line 3 for Chucks: %v", cfgErr.Line, cfgErr.Venue, err)
+	}
+}
+
+// selectors are enough for an html-table venue.
+const selectors = `"selectors": {"table": "table", "brewery": "td.b", "name": "td.n"}`
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		line, col int
+		venue     string
+		msg       string
+	}{
+		{"empty", ``, 1, 1, "", "unexpected end of file"},
+		{"not an array", `{"name": "Pub"}`, 1, 1, "", "expected an array of venues"},
+		{"syntax", "[\n  {\"name\": \"Pub\",}\n]", 2, 18, "", "invalid character"},
+		{"unterminated", "[\n  {\"name\": \"Pub\"", 2, 17, "", "unexpected end of file"},
+		{"not a string", "[\n  {\"name\": 5}\n]", 2, 3, "", "name must be a string"},
+		{"missing name", "[\n  {\"url\": \"http://example.com\"}\n]", 2, 3, "", "missing name"},
+		{"missing url", "[\n\t{\"name\": \"Pub\"}\n]", 2, 2, "Pub", "missing url"},
+		{"bad scheme", "[\n  {\"name\": \"Pub\", \"url\": \"ftp://example.com\"}\n]", 2, 3, "Pub", "must be http or https"},
+		{"no slug", "[\n  {\"name\": \"!!!\", \"url\": \"http://example.com\", " + selectors + "}\n]", 2, 3, "!!!", "needs a letter or digit"},
+		{
+			"unknown type",
+			"[\n  {\"name\": \"Pub\", \"url\": \"http://example.com\", " + selectors + "},\n" +
+				"  {\"name\": \"Bar\", \"url\": \"http://example.com\", \"type\": \"carrier-pigeon\"}\n]",
+			3, 3, "Bar", "carrier-pigeon",
+		},
+		{
+			"second on a line",
+			"[{\"name\": \"Pub\", \"url\": \"http://example.com\", " + selectors + "}, {\"name\": \"Bar\"}]",
+			1, 116, "Bar", "missing url",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tt.file))
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("got %v, want a *ConfigError", err)
+			}
+			if cfgErr.Line != tt.line || cfgErr.Column != tt.col || cfgErr.Venue != tt.venue {
+				t.Errorf("got error at %d:%d for venue %q, want %d:%d for %q",
+					cfgErr.Line, cfgErr.Column, cfgErr.Venue, tt.line, tt.col, tt.venue)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("got %q, want it to mention %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	taplisters, err := LoadFile("venues.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taplisters) == 0 {
+		t.Fatal("no venues")
+	}
+	for _, tl := range taplisters {
+		if tl.Venue() == "" || tl.URL() == "" {
+			t.Errorf("venue %q at %q is missing its name or URL", tl.Venue(), tl.URL())
+		}
+	}
+
+	// Errors name the file.
+	f, err := ioutil.TempFile(t.TempDir(), "venues*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("[\n  {\"name\": \"Pub\"}\n]")
+	f.Close()
+	_, err = LoadFile(f.Name())
+	if want := f.Name() + `:2:3: venue "Pub": missing url`; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	for _, name := range []string{"venues.yaml", "venues.YML", "venues.toml"} {
+		_, err := LoadFile(name)
+		if err == nil || !strings.Contains(err.Error(), "only JSON") {
+			t.Errorf("LoadFile(%q) got %v, want an error saying only JSON is supported", name, err)
+		}
+	}
+}
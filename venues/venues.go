@@ -8,6 +8,8 @@ import (
 
 var coll = colly.NewCollector()
 
+// Venues are the built-in venues, which are used when no venues file is
+// given. venues.json has the same venues, as an example of the file format.
 var Venues = []beerweb.Taplister{
 	html.NewTaplist(coll, html.TaplistConfig{
 		Venue:           "Chuck's Hop Shop (Greenwood)",
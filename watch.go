@@ -0,0 +1,165 @@
+package beerweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchRule describes beers that someone wants to hear about as soon as
+// they go on tap. Every field that's set has to match. Text fields match if
+// they're contained in the beer's field, disregarding case.
+type WatchRule struct {
+	// Name identifies the rule in alerts. It defaults to a description of
+	// the rule.
+	Name    string `json:"name,omitempty"`
+	Brewery string `json:"brewery,omitempty"`
+	Beer    string `json:"beer,omitempty"`
+	// Style is a regular expression, matched disregarding case, like
+	// "stout|porter".
+	Style  string  `json:"style,omitempty"`
+	MinABV float64 `json:"min_abv,omitempty"`
+	// Venue matches a venue by slug, or by part of its name.
+	Venue string `json:"venue,omitempty"`
+}
+
+// String describes the rule, like `brewery "fremont", style /stout/`.
+func (r WatchRule) String() string {
+	var parts []string
+	for _, f := range []struct{ name, value string }{
+		{"brewery", r.Brewery},
+		{"beer", r.Beer},
+		{"venue", r.Venue},
+	} {
+		if f.value != "" {
+			parts = append(parts, fmt.Sprintf("%s %q", f.name, f.value))
+		}
+	}
+	if r.Style != "" {
+		parts = append(parts, "style /"+r.Style+"/")
+	}
+	if r.MinABV > 0 {
+		parts = append(parts, fmt.Sprintf("abv >= %v%%", r.MinABV))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReadWatchRules reads a JSON array of rules from the named file.
+func ReadWatchRules(filename string) ([]WatchRule, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rules []WatchRule
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("reading watch rules from %s: %v", filename, err)
+	}
+	return rules, nil
+}
+
+// Alert is raised when a beer that matches a watch rule goes on tap.
+type Alert struct {
+	Rule  string    `json:"rule"`
+	Venue string    `json:"venue"`
+	Beer  Beer      `json:"beer"`
+	Time  time.Time `json:"time"`
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("%s: %s is on tap at %s", a.Rule, a.Beer, a.Venue)
+}
+
+// Watcher checks taplist changes against a set of watch rules. It remembers
+// what it's alerted about, so that a beer that drops off a list and comes
+// back, as happens when a scrape goes wrong, doesn't raise another alert
+// until Cooldown has passed.
+type Watcher struct {
+	// Cooldown is how long to wait before alerting about the same beer at
+	// the same venue for the same rule again.
+	Cooldown time.Duration
+
+	rules []watchMatcher
+
+	mu      sync.Mutex
+	alerted map[string]time.Time
+}
+
+// DefaultCooldown is the Cooldown of a Watcher returned by NewWatcher.
+const DefaultCooldown = 7 * 24 * time.Hour
+
+type watchMatcher struct {
+	WatchRule
+	style *regexp.Regexp
+}
+
+// NewWatcher returns a Watcher for the rules, or an error if any of them
+// are invalid.
+func NewWatcher(rules []WatchRule) (*Watcher, error) {
+	w := &Watcher{
+		Cooldown: DefaultCooldown,
+		alerted:  make(map[string]time.Time),
+	}
+	for i, r := range rules {
+		if r.String() == "" {
+			return nil, fmt.Errorf("watch rule %d: matches every beer", i+1)
+		}
+		m := watchMatcher{WatchRule: r}
+		if m.Name == "" {
+			m.Name = r.String()
+		}
+		if r.Style != "" {
+			var err error
+			if m.style, err = regexp.Compile("(?i)" + r.Style); err != nil {
+				return nil, fmt.Errorf("watch rule %d: bad style pattern: %v", i+1, err)
+			}
+		}
+		w.rules = append(w.rules, m)
+	}
+	return w, nil
+}
+
+func (m watchMatcher) match(venue string, b Beer) bool {
+	f := Filter{Venue: m.Venue, Brewery: m.Brewery, MinABV: m.MinABV}
+	if !f.MatchVenue(venue) || !f.Match(b) || !containsFold(b.Name, m.Beer) {
+		return false
+	}
+	return m.style == nil || m.style.MatchString(b.Style)
+}
+
+// Check returns alerts for the beers in a taplist change that match a rule
+// and weren't on the list before. Beers whose details changed so that they
+// match a rule now count too, like a style being filled in.
+func (w *Watcher) Check(d TaplistDiff, t time.Time) []Alert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var alerts []Alert
+	for _, m := range w.rules {
+		candidates := append([]Beer(nil), d.Added...)
+		for _, c := range d.Changed {
+			// A beer that already matched, and has only had its price
+			// or tap changed, say, is old news.
+			if !m.match(d.Venue, c.Old) && m.match(d.Venue, c.New) {
+				candidates = append(candidates, c.New)
+			}
+		}
+		for _, b := range candidates {
+			if !m.match(d.Venue, b) {
+				continue
+			}
+			key := m.Name + "|" + d.Venue + "|" + b.Key()
+			if last, ok := w.alerted[key]; ok && t.Sub(last) < w.Cooldown {
+				continue
+			}
+			w.alerted[key] = t
+			alerts = append(alerts, Alert{Rule: m.Name, Venue: d.Venue, Beer: b, Time: t})
+		}
+	}
+	return alerts
+}
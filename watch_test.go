@@ -0,0 +1,54 @@
+package beerweb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	stout := Beer{Brewery: "Fremont", Name: "Dark Star", Style: "Oatmeal Stout"}
+	unstyled := Beer{Brewery: "Fremont", Name: "Dark Star"}
+	repriced := stout
+	repriced.Prices = []Price{{Size: "pint", Amount: "$7"}}
+
+	tests := []struct {
+		name string
+		diff TaplistDiff
+		want int
+	}{
+		{"added", TaplistDiff{Venue: "Pub", Added: []Beer{stout}}, 1},
+		{"not a match", TaplistDiff{Venue: "Pub", Added: []Beer{{Brewery: "Fremont", Name: "Lush", Style: "IPA"}}}, 0},
+		{"style filled in", TaplistDiff{Venue: "Pub", Changed: []BeerChange{{Old: unstyled, New: stout, Fields: []string{"style"}}}}, 1},
+		{"price changed", TaplistDiff{Venue: "Pub", Changed: []BeerChange{{Old: stout, New: repriced, Fields: []string{"prices"}}}}, 0},
+		{"removed", TaplistDiff{Venue: "Pub", Removed: []Beer{stout}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWatcher([]WatchRule{{Name: "stouts", Style: "stout|porter"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Check(tt.diff, time.Now()); len(got) != tt.want {
+				t.Errorf("got %d alerts, want %d: %v", len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWatcherCooldown(t *testing.T) {
+	w, err := NewWatcher([]WatchRule{{Brewery: "fremont"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := TaplistDiff{Venue: "Pub", Added: []Beer{{Brewery: "Fremont", Name: "Lush"}}}
+	now := time.Now()
+	if got := w.Check(d, now); len(got) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(got))
+	}
+	if got := w.Check(d, now.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("got %d alerts within the cooldown, want 0", len(got))
+	}
+	if got := w.Check(d, now.Add(w.Cooldown)); len(got) != 1 {
+		t.Errorf("got %d alerts after the cooldown, want 1", len(got))
+	}
+}
@@ -0,0 +1,264 @@
+// Package webhook delivers taplist changes to HTTP endpoints as JSON.
+//
+// Each delivery is a POST of a Payload. If the endpoint has a secret, the
+// request carries an X-Beerweb-Signature header of the form "sha256=<hex>",
+// the HMAC-SHA256 of the body keyed with the secret, which receivers can
+// check with Verify.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// Headers set on every delivery.
+const (
+	SignatureHeader = "X-Beerweb-Signature"
+	EventHeader     = "X-Beerweb-Event"
+	DeliveryHeader  = "X-Beerweb-Delivery"
+)
+
+// Event is the value of the EventHeader for taplist changes.
+const Event = "taplist.changed"
+
+// Endpoint is somewhere to deliver changes to.
+type Endpoint struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries to this endpoint, if it's set.
+	Secret string `json:"secret,omitempty"`
+}
+
+// ReadEndpoints reads a JSON array of endpoints from the named file.
+func ReadEndpoints(filename string) ([]Endpoint, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var endpoints []Endpoint
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&endpoints); err != nil {
+		return nil, fmt.Errorf("reading webhooks from %s: %v", filename, err)
+	}
+	for i, e := range endpoints {
+		if e.URL == "" {
+			return nil, fmt.Errorf("reading webhooks from %s: webhook %d has no url", filename, i+1)
+		}
+	}
+	return endpoints, nil
+}
+
+// Payload is the body of a delivery.
+type Payload struct {
+	Venue     string               `json:"venue"`
+	Added     []beerweb.Beer       `json:"added"`
+	Removed   []beerweb.Beer       `json:"removed"`
+	Changed   []beerweb.BeerChange `json:"changed,omitempty"`
+	Alerts    []beerweb.Alert      `json:"alerts,omitempty"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// NewPayload returns the payload describing a change, along with any watch
+// alerts it raised.
+func NewPayload(c beerweb.TaplistChange, alerts []beerweb.Alert) Payload {
+	p := Payload{
+		Venue:     c.Venue,
+		Added:     c.Added,
+		Removed:   c.Removed,
+		Changed:   c.Changed,
+		Alerts:    alerts,
+		Timestamp: c.Time,
+	}
+	// Receivers shouldn't have to tell null from an empty list.
+	if p.Added == nil {
+		p.Added = []beerweb.Beer{}
+	}
+	if p.Removed == nil {
+		p.Removed = []beerweb.Beer{}
+	}
+	return p
+}
+
+// Delivery records an attempt to deliver a payload to an endpoint, for the
+// delivery log.
+type Delivery struct {
+	ID         string        `json:"id"`
+	Time       time.Time     `json:"time"`
+	URL        string        `json:"url"`
+	Venue      string        `json:"venue"`
+	Attempts   int           `json:"attempts"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// OK reports whether the payload was delivered.
+func (d Delivery) OK() bool {
+	return d.Error == ""
+}
+
+func (d Delivery) String() string {
+	if !d.OK() {
+		return fmt.Sprintf("webhook delivery %s of %s to %s failed after %d attempts: %s",
+			d.ID, d.Venue, d.URL, d.Attempts, d.Error)
+	}
+	return fmt.Sprintf("webhook delivery %s of %s to %s succeeded in %v",
+		d.ID, d.Venue, d.URL, d.Duration)
+}
+
+// Notifier delivers payloads to a set of endpoints.
+type Notifier struct {
+	Endpoints []Endpoint
+	Client    *http.Client
+	// Attempts is how many times to try each delivery. A delivery is
+	// retried if the endpoint can't be reached, or responds with a server
+	// error or 429 Too Many Requests.
+	Attempts int
+	// RetryDelay is how long to wait before the first retry. It doubles
+	// with each attempt after that.
+	RetryDelay time.Duration
+	// Log, if set, is called with the outcome of every delivery.
+	Log func(Delivery)
+}
+
+// New returns a Notifier for the endpoints with reasonable defaults.
+func New(endpoints []Endpoint) *Notifier {
+	return &Notifier{
+		Endpoints:  endpoints,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		Attempts:   4,
+		RetryDelay: 2 * time.Second,
+	}
+}
+
+// Notify delivers the payload to every endpoint at once, and returns how
+// each delivery went.
+func (n *Notifier) Notify(ctx context.Context, p Payload) []Delivery {
+	body, err := json.Marshal(p)
+	if err != nil {
+		// Beers are plain data, so this can't really happen.
+		panic(fmt.Sprintf("webhook: can't encode payload: %v", err))
+	}
+	var (
+		deliveries = make([]Delivery, len(n.Endpoints))
+		wg         sync.WaitGroup
+	)
+	for i, e := range n.Endpoints {
+		wg.Add(1)
+		go func(i int, e Endpoint) {
+			defer wg.Done()
+			d := n.deliver(ctx, e, p.Venue, body)
+			if n.Log != nil {
+				n.Log(d)
+			}
+			deliveries[i] = d
+		}(i, e)
+	}
+	wg.Wait()
+	return deliveries
+}
+
+func (n *Notifier) deliver(ctx context.Context, e Endpoint, venue string, body []byte) Delivery {
+	var (
+		d     = Delivery{ID: newID(), Time: time.Now(), URL: e.URL, Venue: venue}
+		delay = n.RetryDelay
+		err   error
+		retry bool
+	)
+	for d.Attempts < n.Attempts || d.Attempts == 0 {
+		if d.Attempts > 0 {
+			if !sleep(ctx, delay) {
+				err = ctx.Err()
+				break
+			}
+			delay *= 2
+		}
+		d.Attempts++
+		d.StatusCode, retry, err = n.post(ctx, e, d.ID, body)
+		if err == nil || !retry {
+			break
+		}
+	}
+	d.Duration = time.Since(d.Time)
+	if err != nil {
+		d.Error = err.Error()
+	}
+	return d
+}
+
+// post makes a single delivery, reporting whether it's worth trying again
+// if it failed.
+func (n *Notifier) post(ctx context.Context, e Endpoint, id string, body []byte) (int, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "beerweb-webhook")
+	req.Header.Set(EventHeader, Event)
+	req.Header.Set(DeliveryHeader, id)
+	if e.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(e.Secret, body))
+	}
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 == 2 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, fmt.Errorf("endpoint responded %s", resp.Status)
+}
+
+// sleep waits for d, and reports whether it did so without ctx ending first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func newID() string {
+	var b [8]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return fmt.Sprint(time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Sign returns the signature of body with secret, as sent in the
+// SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body with secret.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
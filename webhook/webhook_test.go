@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ianfoo/beerweb"
+)
+
+// receiver is an endpoint that responds to each delivery with the next of
+// its statuses, and then with 200 OK, recording what it was sent.
+type receiver struct {
+	secret   string
+	statuses []int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if n := len(rc.requests); n <= len(rc.statuses) {
+		status = rc.statuses[n-1]
+	}
+	rw.WriteHeader(status)
+}
+
+func testNotifier(endpoints ...Endpoint) *Notifier {
+	n := New(endpoints)
+	n.RetryDelay = time.Millisecond
+	return n
+}
+
+var testPayload = NewPayload(beerweb.TaplistChange{
+	Time: time.Date(2019, 5, 1, 18, 0, 0, 0, time.UTC),
+	TaplistDiff: beerweb.TaplistDiff{
+		Venue: "Local Pub",
+		Added: []beerweb.Beer{{Brewery: "Fremont", Name: "Dark Star"}},
+	},
+}, nil)
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		status   int
+		ok       bool
+	}{
+		{"first time", nil, 1, 200, true},
+		{"after a server error", []int{500}, 2, 200, true},
+		{"after being throttled", []int{429, 503}, 3, 200, true},
+		{"not on a client error", []int{400}, 1, 400, false},
+		{"not on a redirect", []int{304}, 1, 304, false},
+		{"until out of attempts", []int{500, 502, 503, 504, 500}, 4, 504, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			ts := httptest.NewServer(rc)
+			defer ts.Close()
+
+			var logged []Delivery
+			n := testNotifier(Endpoint{URL: ts.URL})
+			n.Log = func(d Delivery) { logged = append(logged, d) }
+			ds := n.Notify(context.Background(), testPayload)
+			if len(ds) != 1 {
+				t.Fatalf("got %d deliveries, want 1", len(ds))
+			}
+			d := ds[0]
+			if d.Attempts != tt.attempts || d.StatusCode != tt.status || d.OK() != tt.ok {
+				t.Errorf("got %d attempts, status %d, ok %v; want %d, %d, %v",
+					d.Attempts, d.StatusCode, d.OK(), tt.attempts, tt.status, tt.ok)
+			}
+			if len(rc.requests) != tt.attempts {
+				t.Errorf("endpoint got %d requests, want %d", len(rc.requests), tt.attempts)
+			}
+			// Retries are the same delivery.
+			for _, r := range rc.requests {
+				if id := r.Header.Get(DeliveryHeader); id != d.ID {
+					t.Errorf("delivery ID %q, want %q", id, d.ID)
+				}
+			}
+			if len(logged) != 1 || logged[0].ID != d.ID {
+				t.Errorf("logged %v, want the delivery", logged)
+			}
+		})
+	}
+}
+
+func TestNotifyUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+	n := testNotifier(Endpoint{URL: ts.URL})
+	n.Attempts = 2
+	d := n.Notify(context.Background(), testPayload)[0]
+	if d.OK() || d.Attempts != 2 || d.StatusCode != 0 {
+		t.Errorf("got %+v, want 2 failed attempts", d)
+	}
+}
+
+func TestNotifyCanceled(t *testing.T) {
+	rc := &receiver{statuses: []int{500}}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+	n := testNotifier(Endpoint{URL: ts.URL})
+	n.RetryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	d := n.Notify(ctx, testPayload)[0]
+	if d.OK() || d.Attempts != 1 || d.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("got %+v, want to give up waiting to retry", d)
+	}
+}
+
+func TestNotifySigned(t *testing.T) {
+	signed := &receiver{secret: "hunter2"}
+	unsigned := &receiver{}
+	tsSigned := httptest.NewServer(signed)
+	defer tsSigned.Close()
+	tsUnsigned := httptest.NewServer(unsigned)
+	defer tsUnsigned.Close()
+
+	n := testNotifier(
+		Endpoint{URL: tsSigned.URL, Secret: signed.secret},
+		Endpoint{URL: tsUnsigned.URL},
+	)
+	for _, d := range n.Notify(context.Background(), testPayload) {
+		if !d.OK() {
+			t.Errorf("delivery to %s failed: %s", d.URL, d.Error)
+		}
+	}
+
+	for _, rc := range []*receiver{signed, unsigned} {
+		if len(rc.requests) != 1 {
+			t.Fatalf("endpoint got %d requests, want 1", len(rc.requests))
+		}
+		r, body := rc.requests[0], rc.bodies[0]
+		if r.Method != http.MethodPost {
+			t.Errorf("method %s, want POST", r.Method)
+		}
+		for h, want := range map[string]string{
+			"Content-Type": "application/json",
+			EventHeader:    Event,
+		} {
+			if got := r.Header.Get(h); got != want {
+				t.Errorf("%s is %q, want %q", h, got, want)
+			}
+		}
+
+		sig := r.Header.Get(SignatureHeader)
+		switch {
+		case rc.secret == "" && sig != "":
+			t.Errorf("unsigned delivery has signature %q", sig)
+		case rc.secret != "" && !Verify(rc.secret, body, sig):
+			t.Errorf("signature %q doesn't verify", sig)
+		}
+
+		var p Payload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Venue != "Local Pub" || len(p.Added) != 1 || p.Removed == nil {
+			t.Errorf("got payload %+v", p)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"venue":"Local Pub"}`)
+	sig := Sign("hunter2", body)
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"good", "hunter2", body, sig, true},
+		{"wrong secret", "hunter3", body, sig, false},
+		{"changed body", "hunter2", []byte(`{"venue":"Other Pub"}`), sig, false},
+		{"no prefix", "hunter2", body, sig[len("sha256="):], false},
+		{"empty", "hunter2", body, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
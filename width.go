@@ -0,0 +1,260 @@
+package beerweb
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DisplayWidth returns the number of columns s takes up in a terminal.
+// East Asian wide characters and most emoji take two, and combining marks,
+// variation selectors and the parts of joined emoji sequences take none.
+func DisplayWidth(s string) int {
+	var w int
+	for _, c := range clusters(s) {
+		w += c.width
+	}
+	return w
+}
+
+// TruncateWidth shortens s to fit in width columns, ending it with "…" if
+// anything had to be cut.
+func TruncateWidth(s string, width int) string {
+	if DisplayWidth(s) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	head, _ := splitWidth(s, width-1)
+	return head + "…"
+}
+
+// WrapWidth breaks s into lines that fit in width columns, between words
+// where it can. There's always at least one line, even if s is empty.
+func WrapWidth(s string, width int) []string {
+	var (
+		lines []string
+		line  strings.Builder
+		lineW int
+	)
+	for _, word := range strings.Fields(s) {
+		w := DisplayWidth(word)
+		if lineW > 0 && lineW+1+w <= width {
+			line.WriteByte(' ')
+			line.WriteString(word)
+			lineW += 1 + w
+			continue
+		}
+		if lineW > 0 {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		// Words too long for a line of their own are broken wherever.
+		for w > width {
+			head, rest := splitWidth(word, width)
+			if head == "" {
+				// Not even one character fits, and none are wider
+				// than two columns.
+				head, rest = splitWidth(word, 2)
+			}
+			lines = append(lines, head)
+			word = rest
+			w = DisplayWidth(word)
+		}
+		line.WriteString(word)
+		lineW = w
+	}
+	if lineW > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// splitWidth splits s after as much of it as fits in width columns.
+func splitWidth(s string, width int) (head, tail string) {
+	var n, w int
+	for _, c := range clusters(s) {
+		if w+c.width > width {
+			break
+		}
+		n += len(c.text)
+		w += c.width
+	}
+	return s[:n], s[n:]
+}
+
+// cluster is a character as it's displayed, possibly made up of several
+// runes, like a letter and its accents or a sequence of joined emoji.
+type cluster struct {
+	text  string
+	width int
+}
+
+const (
+	zeroWidthJoiner = '\u200d'
+	emojiStyle      = '\ufe0f'
+)
+
+// clusters splits s into the characters it's displayed as. This isn't the
+// full Unicode segmentation algorithm, but it's good enough to line up
+// columns of beer names.
+func clusters(s string) []cluster {
+	var (
+		cs     []cluster
+		joined bool
+		prev   rune
+	)
+	for i, r := range s {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		if len(cs) == 0 || !continues(&cs[len(cs)-1], r, prev, &joined) {
+			cs = append(cs, cluster{width: runeWidth(r)})
+		}
+		cs[len(cs)-1].text += s[i : i+size]
+		prev = r
+	}
+	return cs
+}
+
+// continues reports whether r is part of the character c, the one before it,
+// and widens c if r makes it wide.
+func continues(c *cluster, r, prev rune, joined *bool) bool {
+	switch {
+	case *joined:
+		// An emoji joined to the one before, like the parts of a family,
+		// is drawn with it.
+		*joined = false
+	case r == zeroWidthJoiner:
+		*joined = true
+	case r == emojiStyle:
+		// Asks for a symbol to be drawn as a wide emoji.
+		if c.width == 1 {
+			c.width = 2
+		}
+	case isSkinTone(r) && c.width == 2:
+	case isRegionalIndicator(r) && isRegionalIndicator(prev) && c.width == 1:
+		// Completes a flag.
+		c.width = 2
+	case runeWidth(r) == 0:
+	default:
+		return false
+	}
+	return true
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc):
+		return 0
+	case unicode.Is(wide, r):
+		return 2
+	}
+	return 1
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// wide holds the characters that are East Asian Wide or Fullwidth, which
+// includes the emoji that are drawn as such by default.
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f0, 1},
+		{0x23f3, 0x23f3, 1},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x267f, 1},
+		{0x2693, 0x2693, 1},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26ce, 1},
+		{0x26d4, 0x26d4, 1},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26f5, 1},
+		{0x26fa, 0x26fa, 1},
+		{0x26fd, 0x26fd, 1},
+		{0x2705, 0x2705, 1},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x2728, 1},
+		{0x274c, 0x274c, 1},
+		{0x274e, 0x274e, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27b0, 1},
+		{0x27bf, 0x27bf, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b50, 1},
+		{0x2b55, 0x2b55, 1},
+		{0x2e80, 0x303e, 1},
+		{0x3041, 0x33ff, 1},
+		{0x3400, 0x4dbf, 1},
+		{0x4e00, 0x9fff, 1},
+		{0xa000, 0xa4cf, 1},
+		{0xa960, 0xa97f, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe6f, 1},
+		{0xff00, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x17000, 0x18aff, 1},
+		{0x1b000, 0x1b2ff, 1},
+		{0x1f004, 0x1f004, 1},
+		{0x1f0cf, 0x1f0cf, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f200, 0x1f202, 1},
+		{0x1f210, 0x1f23b, 1},
+		{0x1f240, 0x1f248, 1},
+		{0x1f250, 0x1f251, 1},
+		{0x1f260, 0x1f265, 1},
+		{0x1f300, 0x1f320, 1},
+		{0x1f32d, 0x1f335, 1},
+		{0x1f337, 0x1f37c, 1},
+		{0x1f37e, 0x1f393, 1},
+		{0x1f3a0, 0x1f3ca, 1},
+		{0x1f3cf, 0x1f3d3, 1},
+		{0x1f3e0, 0x1f3f0, 1},
+		{0x1f3f4, 0x1f3f4, 1},
+		{0x1f3f8, 0x1f43e, 1},
+		{0x1f440, 0x1f440, 1},
+		{0x1f442, 0x1f4fc, 1},
+		{0x1f4ff, 0x1f53d, 1},
+		{0x1f54b, 0x1f54e, 1},
+		{0x1f550, 0x1f567, 1},
+		{0x1f57a, 0x1f57a, 1},
+		{0x1f595, 0x1f596, 1},
+		{0x1f5a4, 0x1f5a4, 1},
+		{0x1f5fb, 0x1f64f, 1},
+		{0x1f680, 0x1f6c5, 1},
+		{0x1f6cc, 0x1f6cc, 1},
+		{0x1f6d0, 0x1f6d2, 1},
+		{0x1f6d5, 0x1f6d7, 1},
+		{0x1f6eb, 0x1f6ec, 1},
+		{0x1f6f4, 0x1f6fc, 1},
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f90c, 0x1f93a, 1},
+		{0x1f93c, 0x1f945, 1},
+		{0x1f947, 0x1f9ff, 1},
+		{0x1fa70, 0x1faff, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
@@ -0,0 +1,92 @@
+package beerweb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"Lush", 4},
+		{"Märzen", 6},
+		{"Ma\u0308rzen", 6}, // a and a combining diaeresis
+		{"Kölsch", 6},
+		{"日本酒", 6},
+		{"ビール IPA", 10},
+		{"１２３", 6}, // fullwidth digits
+		{"🍺", 2},
+		{"Beer 🍻!", 8},
+		{"❄", 1},
+		{"❄\ufe0f", 2},         // drawn as an emoji
+		{"👍🏽", 2},              // with a skin tone
+		{"👨\u200d👩\u200d👧", 2}, // a family
+		{"🇧🇪", 2},              // a flag
+		{"🇧", 1},               // half of one
+		{"a\u200bb", 2},        // with a zero-width space
+		{"tab\there", 7},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.in); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"Lush", 4, "Lush"},
+		{"Lush", 10, "Lush"},
+		{"Dark Star", 5, "Dark…"},
+		{"Dark Star", 1, "…"},
+		{"Dark Star", 0, ""},
+		{"Ma\u0308rzen", 3, "Ma\u0308…"},
+		{"日本酒", 5, "日本…"},
+		{"日本酒", 4, "日…"}, // the second character won't fit beside the …
+		{"🍺🍺🍺", 4, "🍺…"},
+		{"👨\u200d👩\u200d👧 family", 3, "👨\u200d👩\u200d👧…"},
+	}
+	for _, tt := range tests {
+		got := TruncateWidth(tt.in, tt.width)
+		if got != tt.want {
+			t.Errorf("TruncateWidth(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+		if w := DisplayWidth(got); w > tt.width {
+			t.Errorf("TruncateWidth(%q, %d) is %d wide", tt.in, tt.width, w)
+		}
+	}
+}
+
+func TestWrapWidth(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"   ", 10, []string{""}},
+		{"Lush", 10, []string{"Lush"}},
+		{"Pliny the Elder", 9, []string{"Pliny the", "Elder"}},
+		{"Pliny the Elder", 5, []string{"Pliny", "the", "Elder"}},
+		{"  Pliny   the\nElder ", 20, []string{"Pliny the Elder"}},
+		{"Doppelbock", 4, []string{"Dopp", "elbo", "ck"}},
+		{"a Doppelbock b", 6, []string{"a", "Doppel", "bock b"}},
+		{"日本酒 ビール", 6, []string{"日本酒", "ビール"}},
+		{"日本酒", 3, []string{"日", "本", "酒"}},
+		// A line too narrow for any one character still makes progress.
+		{"日本", 1, []string{"日", "本"}},
+		{"🍺 Beer", 4, []string{"🍺", "Beer"}},
+	}
+	for _, tt := range tests {
+		if got := WrapWidth(tt.in, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WrapWidth(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}